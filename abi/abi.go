@@ -0,0 +1,352 @@
+// Package abi encodes contract calls and decodes transaction input, event logs and revert data with Solidity JSON ABIs.
+package abi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/sha3"
+
+	"github.com/rafaribe/polygon-client/rpc"
+)
+
+// wordSize is the size in bytes of an ABI encoded word.
+const wordSize = 32
+
+// Errors returned when the input, the log or the revert data does not match any method, event or error of the ABI.
+var (
+	ErrUnknownMethod = errors.New("unknown method")
+	ErrUnknownEvent  = errors.New("unknown event")
+	ErrUnknownError  = errors.New("unknown error")
+)
+
+// Argument is a named and typed input or output of a method, an event or an error.
+type Argument struct {
+	Name string
+	Type Type
+	// Indexed is set for event arguments stored in the log topics
+	Indexed bool
+}
+
+// Arguments is the ordered list of arguments of a method, an event or an error.
+type Arguments []Argument
+
+// types returns the comma separated canonical types of the arguments.
+func (args Arguments) types() string {
+	types := make([]string, len(args))
+	for i, arg := range args {
+		types[i] = arg.Type.String()
+	}
+	return strings.Join(types, ",")
+}
+
+// Method is a contract function, identified in transaction input by its selector.
+type Method struct {
+	Name    string
+	Inputs  Arguments
+	Outputs Arguments
+	// StateMutability is one of pure, view, nonpayable or payable
+	StateMutability string
+	// Selector is the first 4 bytes of the Keccak-256 hash of the signature
+	Selector [4]byte
+}
+
+// Signature returns the canonical signature of the method, e.g. transfer(address,uint256).
+func (m *Method) Signature() string {
+	return m.Name + "(" + m.Inputs.types() + ")"
+}
+
+// Event is a contract event, identified in non anonymous logs by the hash of its signature in the first topic.
+type Event struct {
+	Name      string
+	Inputs    Arguments
+	Anonymous bool
+	// ID is the Keccak-256 hash of the signature
+	ID rpc.Hash
+}
+
+// Signature returns the canonical signature of the event, e.g. Transfer(address,address,uint256).
+func (e *Event) Signature() string {
+	return e.Name + "(" + e.Inputs.types() + ")"
+}
+
+// indexed returns the number of arguments stored in topics.
+func (e *Event) indexed() int {
+	n := 0
+	for _, arg := range e.Inputs {
+		if arg.Indexed {
+			n++
+		}
+	}
+	return n
+}
+
+// Error is a custom error a contract reverts with, identified in revert data by its selector.
+type Error struct {
+	Name     string
+	Inputs   Arguments
+	Selector [4]byte
+}
+
+// Signature returns the canonical signature of the error, e.g. InsufficientBalance(uint256,uint256).
+func (e *Error) Signature() string {
+	return e.Name + "(" + e.Inputs.types() + ")"
+}
+
+// ABI is the interface of a contract.
+// Overloaded methods and events are all kept, in the order they were declared.
+type ABI struct {
+	Methods []*Method
+	Events  []*Event
+	Errors  []*Error
+}
+
+// jsonArgument is an argument as written in a JSON ABI.
+type jsonArgument struct {
+	Name       string         `json:"name"`
+	Type       string         `json:"type"`
+	Indexed    bool           `json:"indexed"`
+	Components []jsonArgument `json:"components"`
+}
+
+// jsonEntry is a function, event, error, constructor, fallback or receive entry of a JSON ABI.
+type jsonEntry struct {
+	Type            string         `json:"type"`
+	Name            string         `json:"name"`
+	Inputs          []jsonArgument `json:"inputs"`
+	Outputs         []jsonArgument `json:"outputs"`
+	StateMutability string         `json:"stateMutability"`
+	Anonymous       bool           `json:"anonymous"`
+}
+
+// Parse parses a JSON ABI, as output by the Solidity compiler.
+// Constructor, fallback and receive entries are ignored, they cannot be identified in transaction input.
+func Parse(data []byte) (*ABI, error) {
+	var entries []jsonEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("error unmarshalling ABI: %v", err)
+	}
+
+	a := &ABI{}
+	for _, entry := range entries {
+		inputs, err := parseArguments(entry.Inputs)
+		if err != nil {
+			return nil, fmt.Errorf("invalid inputs of %s: %v", entry.Name, err)
+		}
+		switch entry.Type {
+		case "function", "":
+			outputs, err := parseArguments(entry.Outputs)
+			if err != nil {
+				return nil, fmt.Errorf("invalid outputs of %s: %v", entry.Name, err)
+			}
+			m := &Method{Name: entry.Name, Inputs: inputs, Outputs: outputs, StateMutability: entry.StateMutability}
+			copy(m.Selector[:], keccak256([]byte(m.Signature())))
+			a.Methods = append(a.Methods, m)
+		case "event":
+			e := &Event{Name: entry.Name, Inputs: inputs, Anonymous: entry.Anonymous}
+			copy(e.ID[:], keccak256([]byte(e.Signature())))
+			a.Events = append(a.Events, e)
+		case "error":
+			e := &Error{Name: entry.Name, Inputs: inputs}
+			copy(e.Selector[:], keccak256([]byte(e.Signature())))
+			a.Errors = append(a.Errors, e)
+		}
+	}
+	return a, nil
+}
+
+// Load parses a JSON ABI read from r.
+func Load(r io.Reader) (*ABI, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("error reading ABI: %v", err)
+	}
+	return Parse(data)
+}
+
+// LoadFile parses the JSON ABI file at path.
+// Hardhat and Foundry artifacts, which hold the ABI under an abi key, are accepted as well.
+func LoadFile(path string) (*ABI, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading ABI file: %v", err)
+	}
+	if trimmed := bytes.TrimLeft(data, " \t\r\n"); len(trimmed) > 0 && trimmed[0] == '{' {
+		var artifact struct {
+			ABI json.RawMessage `json:"abi"`
+		}
+		if err := json.Unmarshal(data, &artifact); err != nil {
+			return nil, fmt.Errorf("error unmarshalling ABI file %s: %v", path, err)
+		}
+		data = artifact.ABI
+	}
+	a, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing ABI file %s: %v", path, err)
+	}
+	return a, nil
+}
+
+func parseArguments(args []jsonArgument) (Arguments, error) {
+	parsed := make(Arguments, len(args))
+	for i, arg := range args {
+		components, err := parseArguments(arg.Components)
+		if err != nil {
+			return nil, err
+		}
+		t, err := ParseType(arg.Type, components)
+		if err != nil {
+			return nil, err
+		}
+		parsed[i] = Argument{Name: arg.Name, Type: t, Indexed: arg.Indexed}
+	}
+	return parsed, nil
+}
+
+// Method returns the first method with the given name.
+func (a *ABI) Method(name string) (*Method, bool) {
+	for _, m := range a.Methods {
+		if m.Name == name {
+			return m, true
+		}
+	}
+	return nil, false
+}
+
+// Event returns the first event with the given name.
+func (a *ABI) Event(name string) (*Event, bool) {
+	for _, e := range a.Events {
+		if e.Name == name {
+			return e, true
+		}
+	}
+	return nil, false
+}
+
+// MethodBySelector returns the method the transaction input calls, identified by its first 4 bytes.
+func (a *ABI) MethodBySelector(input []byte) (*Method, bool) {
+	if len(input) < 4 {
+		return nil, false
+	}
+	for _, m := range a.Methods {
+		if bytes.Equal(m.Selector[:], input[:4]) {
+			return m, true
+		}
+	}
+	return nil, false
+}
+
+// EventByTopics returns the event that emitted a log with the given topics.
+// Events sharing a signature but not their indexed arguments, like the ERC-20 and ERC-721 Transfer, are told apart by the number of topics.
+func (a *ABI) EventByTopics(topics []rpc.Hash) (*Event, bool) {
+	if len(topics) == 0 {
+		return nil, false
+	}
+	for _, e := range a.Events {
+		if !e.Anonymous && e.ID == topics[0] && e.indexed() == len(topics)-1 {
+			return e, true
+		}
+	}
+	return nil, false
+}
+
+// ErrorBySelector returns the custom error the revert data encodes, identified by its first 4 bytes.
+func (a *ABI) ErrorBySelector(data []byte) (*Error, bool) {
+	if len(data) < 4 {
+		return nil, false
+	}
+	for _, e := range a.Errors {
+		if bytes.Equal(e.Selector[:], data[:4]) {
+			return e, true
+		}
+	}
+	return nil, false
+}
+
+// Call is a decoded transaction input.
+type Call struct {
+	Method *Method
+	Args   []Value
+}
+
+// DecodeInput decodes the method and arguments of a transaction input.
+// It returns ErrUnknownMethod when the ABI has no method with the input selector.
+func (a *ABI) DecodeInput(input []byte) (*Call, error) {
+	m, ok := a.MethodBySelector(input)
+	if !ok {
+		return nil, ErrUnknownMethod
+	}
+	args, err := m.Inputs.Unpack(input[4:])
+	if err != nil {
+		return nil, fmt.Errorf("error decoding %s input: %v", m.Name, err)
+	}
+	return &Call{Method: m, Args: args}, nil
+}
+
+// DecodedLog is a decoded event log.
+type DecodedLog struct {
+	Event *Event
+	// Args holds every argument in declaration order, indexed ones included.
+	// Indexed arguments of dynamic types, which logs only store the Keccak-256 hash of, are decoded as an rpc.Hash.
+	Args []Value
+}
+
+// DecodeLog decodes the event and arguments of a log.
+// It returns ErrUnknownEvent when the ABI has no event matching the log topics.
+func (a *ABI) DecodeLog(log *rpc.Log) (*DecodedLog, error) {
+	e, ok := a.EventByTopics(log.Topics)
+	if !ok {
+		return nil, ErrUnknownEvent
+	}
+	args, err := e.decode(log.Topics[1:], log.Data)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding %s log: %v", e.Name, err)
+	}
+	return &DecodedLog{Event: e, Args: args}, nil
+}
+
+// decode decodes the indexed arguments from topics, without the event ID, and the others from data.
+func (e *Event) decode(topics []rpc.Hash, data []byte) ([]Value, error) {
+	var nonIndexed Arguments
+	for _, arg := range e.Inputs {
+		if !arg.Indexed {
+			nonIndexed = append(nonIndexed, arg)
+		}
+	}
+	unpacked, err := nonIndexed.Unpack(data)
+	if err != nil {
+		return nil, err
+	}
+
+	args := make([]Value, len(e.Inputs))
+	for i, arg := range e.Inputs {
+		if !arg.Indexed {
+			args[i], unpacked = unpacked[0], unpacked[1:]
+			continue
+		}
+		topic := topics[0]
+		topics = topics[1:]
+		args[i] = Value{Name: arg.Name, Type: arg.Type, Value: topic}
+		switch arg.Type.Kind {
+		case BytesKind, StringKind, SliceKind, ArrayKind, TupleKind:
+			continue
+		}
+		v, err := decodeValue(arg.Type, topic[:])
+		if err != nil {
+			return nil, fmt.Errorf("error decoding indexed argument %s: %v", arg.Name, err)
+		}
+		args[i].Value = v
+	}
+	return args, nil
+}
+
+func keccak256(data []byte) []byte {
+	h := sha3.NewLegacyKeccak256()
+	h.Write(data)
+	return h.Sum(nil)
+}
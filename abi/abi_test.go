@@ -0,0 +1,224 @@
+package abi
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rafaribe/polygon-client/rpc"
+)
+
+func TestBuiltinSelectorsAndIDs(t *testing.T) {
+	tests := []struct {
+		abi      *ABI
+		name     string
+		expected string
+	}{
+		{ERC20(), "transfer", "a9059cbb"},
+		{ERC20(), "approve", "095ea7b3"},
+		{ERC20(), "balanceOf", "70a08231"},
+		{ERC721(), "safeTransferFrom", "42842e0e"},
+		{ERC1155(), "safeTransferFrom", "f242432a"},
+		{ERC20(), "Transfer", "ddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef"},
+		{ERC20(), "Approval", "8c5be1e5ebec7d5bd14f71427d1e84f3dd0314c0f7b2291e5b200ac8c7c3b925"},
+		{ERC1155(), "TransferSingle", "c3d58168c5ae7397731d063d5bbf3d657854427343f4c083240f7aacaa2d0f62"},
+		{ERC1155(), "TransferBatch", "4a39dc06d4c0dbc64b70af90fd698a233a518aa5d07e595d983b8c0526c8f7fb"},
+		{PoSBridge(), "LogFeeTransfer", "4dfe1bbbcf077ddc3e01291eea2d5c70c2b422b415d95645b9adcfd678cb1d63"},
+	}
+
+	for _, tt := range tests {
+		var got string
+		if m, ok := tt.abi.Method(tt.name); ok {
+			got = hex.EncodeToString(m.Selector[:])
+		} else if e, ok := tt.abi.Event(tt.name); ok {
+			got = hex.EncodeToString(e.ID[:])
+		} else {
+			t.Errorf("%s not found", tt.name)
+			continue
+		}
+		if got != tt.expected {
+			t.Errorf("expected %s to be identified by %s, got %s", tt.name, tt.expected, got)
+		}
+	}
+}
+
+func TestFunctionType(t *testing.T) {
+	a := mustParse(t, `[
+		{"type":"function","name":"execute","inputs":[{"name":"target","type":"address"},{"name":"callback","type":"function"}]},
+		{"type":"event","name":"Scheduled","inputs":[{"name":"callback","type":"function","indexed":true},{"name":"at","type":"uint256"}]}
+	]`)
+
+	m, ok := a.Method("execute")
+	if !ok {
+		t.Fatalf("execute not found")
+	}
+	if m.Signature() != "execute(address,function)" {
+		t.Errorf("expected signature execute(address,function), got %s", m.Signature())
+	}
+	if got := hex.EncodeToString(m.Selector[:]); got != "3ea2836a" {
+		t.Errorf("expected selector 3ea2836a, got %s", got)
+	}
+	e, ok := a.Event("Scheduled")
+	if !ok {
+		t.Fatalf("Scheduled not found")
+	}
+	if got := hex.EncodeToString(e.ID[:]); got != "27077950c521ff2590db4ab8c0e6107de533f148cda830e0b7ef9945ebde7943" {
+		t.Errorf("expected Scheduled to be identified by 27077950c521ff2590db4ab8c0e6107de533f148cda830e0b7ef9945ebde7943, got %s", got)
+	}
+
+	// A function is the address of the contract followed by the selector of the function, right padded like bytes24
+	target, _ := rpc.HexToAddress("0xc2132d05d31c914a87c6611c10748aeb04b58e8f")
+	callback := append(target[:], 0xa9, 0x05, 0x9c, 0xbb)
+	input, err := m.Pack(target, callback)
+	if err != nil {
+		t.Fatalf("Pack returned unexpected error: %v", err)
+	}
+	if word := hex.EncodeToString(input[4+32:]); word != hex.EncodeToString(callback)+"0000000000000000" {
+		t.Errorf("expected callback word %x0000000000000000, got %s", callback, word)
+	}
+	call, err := a.DecodeInput(input)
+	if err != nil {
+		t.Fatalf("DecodeInput returned unexpected error: %v", err)
+	}
+	if got, ok := call.Args[1].Value.([]byte); !ok || !bytes.Equal(got, callback) {
+		t.Errorf("expected callback %x, got %v", callback, call.Args[1].Value)
+	}
+}
+
+func topic(t *testing.T, s string) rpc.Hash {
+	t.Helper()
+	h, err := rpc.HexToHash(s)
+	if err != nil {
+		t.Fatalf("invalid topic %s: %v", s, err)
+	}
+	return h
+}
+
+func TestRegistryDecodeLog(t *testing.T) {
+	transfer := "0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef"
+	from := "0x0000000000000000000000006e1f5c1c8b7c1bb0b2b1f9b9b0d7e6f1c2a3b4c5"
+	to := "0x000000000000000000000000a0b86991c6218b36c1d19d4a2e9eb0ce3606eb48"
+
+	tests := []struct {
+		name     string
+		log      rpc.Log
+		event    string
+		expected map[string]string
+	}{
+		{
+			name: "ERC-20 transfer",
+			log: rpc.Log{
+				Topics: []rpc.Hash{topic(t, transfer), topic(t, from), topic(t, to)},
+				Data:   hexData(t, "00000000000000000000000000000000000000000000000000000000000f4240"),
+			},
+			event:    "Transfer(address,address,uint256)",
+			expected: map[string]string{"from": "0x6e1f5c1c8b7c1bb0b2b1f9b9b0d7e6f1c2a3b4c5", "to": "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48", "value": "1000000"},
+		},
+		{
+			name: "ERC-721 transfer, told apart by its indexed token id",
+			log: rpc.Log{
+				Topics: []rpc.Hash{topic(t, transfer), topic(t, from), topic(t, to), topic(t, "0x000000000000000000000000000000000000000000000000000000000000002a")},
+			},
+			event:    "Transfer(address,address,uint256)",
+			expected: map[string]string{"tokenId": "42"},
+		},
+		{
+			name: "ERC-1155 batch transfer",
+			log: rpc.Log{
+				Topics: []rpc.Hash{topic(t, "0x4a39dc06d4c0dbc64b70af90fd698a233a518aa5d07e595d983b8c0526c8f7fb"), topic(t, from), topic(t, from), topic(t, to)},
+				Data: hexData(t,
+					"0000000000000000000000000000000000000000000000000000000000000040",
+					"00000000000000000000000000000000000000000000000000000000000000a0",
+					"0000000000000000000000000000000000000000000000000000000000000002",
+					"0000000000000000000000000000000000000000000000000000000000000001",
+					"0000000000000000000000000000000000000000000000000000000000000002",
+					"0000000000000000000000000000000000000000000000000000000000000002",
+					"000000000000000000000000000000000000000000000000000000000000000a",
+					"0000000000000000000000000000000000000000000000000000000000000014",
+				),
+			},
+			event:    "TransferBatch(address,address,address,uint256[],uint256[])",
+			expected: map[string]string{"ids": "[1, 2]", "values": "[10, 20]"},
+		},
+	}
+
+	registry := DefaultRegistry()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decoded, err := registry.DecodeLog(&tt.log)
+			if err != nil {
+				t.Fatalf("DecodeLog returned unexpected error: %v", err)
+			}
+			if decoded.Event.Signature() != tt.event {
+				t.Errorf("expected event %s, got %s", tt.event, decoded.Event.Signature())
+			}
+			args := make(map[string]string)
+			for _, arg := range decoded.Args {
+				args[arg.Name] = arg.String()
+			}
+			for name, value := range tt.expected {
+				if args[name] != value {
+					t.Errorf("expected %s=%s, got %q", name, value, args[name])
+				}
+			}
+		})
+	}
+
+	unknown := rpc.Log{Topics: []rpc.Hash{topic(t, "0x0000000000000000000000000000000000000000000000000000000000000001")}}
+	if _, err := registry.DecodeLog(&unknown); !errors.Is(err, ErrUnknownEvent) {
+		t.Errorf("expected ErrUnknownEvent, got %v", err)
+	}
+}
+
+func TestRegistryLoadDir(t *testing.T) {
+	dir := t.TempDir()
+	contract := "0x1111111111111111111111111111111111111111"
+	// A Hardhat artifact bound to a single contract, and a plain ABI tried for every contract
+	files := map[string]string{
+		contract + ".json": `{"contractName":"Vault","abi":[{"type":"function","name":"transfer","inputs":[{"name":"recipient","type":"address"},{"name":"shares","type":"uint256"}]}]}`,
+		"staking.json":     `[{"type":"function","name":"stake","inputs":[{"name":"amount","type":"uint256"}]}]`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatalf("error writing ABI file: %v", err)
+		}
+	}
+
+	registry := DefaultRegistry()
+	if err := registry.LoadDir(dir); err != nil {
+		t.Fatalf("LoadDir returned unexpected error: %v", err)
+	}
+
+	input := hexData(t, "a9059cbb",
+		"000000000000000000000000a0b86991c6218b36c1d19d4a2e9eb0ce3606eb48",
+		"0000000000000000000000000000000000000000000000000000000000000064",
+	)
+	vault, _ := rpc.HexToAddress(contract)
+	for _, tt := range []struct {
+		to       rpc.Address
+		expected string
+	}{
+		{vault, "recipient"},
+		{rpc.Address{}, "to"},
+	} {
+		call, err := registry.DecodeInput(tt.to, input)
+		if err != nil {
+			t.Fatalf("DecodeInput returned unexpected error: %v", err)
+		}
+		if call.Args[0].Name != tt.expected {
+			t.Errorf("expected first argument %s for contract %s, got %s", tt.expected, tt.to, call.Args[0].Name)
+		}
+	}
+
+	stake := append(keccak256([]byte("stake(uint256)"))[:4], hexData(t, "0000000000000000000000000000000000000000000000000000000000000001")...)
+	if call, err := registry.DecodeInput(rpc.Address{}, stake); err != nil || call.Method.Name != "stake" {
+		t.Errorf("expected stake call, got %v, %v", call, err)
+	}
+
+	if err := NewRegistry().LoadDir(filepath.Join(dir, "missing")); err == nil {
+		t.Errorf("expected an error for a missing directory")
+	}
+}
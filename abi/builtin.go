@@ -0,0 +1,42 @@
+package abi
+
+import (
+	"embed"
+	"fmt"
+	"sync"
+)
+
+//go:embed abis/*.json
+var builtinFiles embed.FS
+
+// builtin parses the embedded ABI file once, the files are part of the package so a parse error is a bug.
+func builtin(name string) func() *ABI {
+	return sync.OnceValue(func() *ABI {
+		data, err := builtinFiles.ReadFile("abis/" + name)
+		if err != nil {
+			panic(fmt.Sprintf("missing built-in ABI %s: %v", name, err))
+		}
+		a, err := Parse(data)
+		if err != nil {
+			panic(fmt.Sprintf("invalid built-in ABI %s: %v", name, err))
+		}
+		return a
+	})
+}
+
+var (
+	// ERC20 returns the ABI of ERC-20 fungible tokens.
+	ERC20 = builtin("erc20.json")
+	// ERC721 returns the ABI of ERC-721 non fungible tokens.
+	ERC721 = builtin("erc721.json")
+	// ERC1155 returns the ABI of ERC-1155 multi tokens.
+	ERC1155 = builtin("erc1155.json")
+	// PoSBridge returns the ABI of the Polygon PoS bridge: the RootChainManager and predicates on Ethereum,
+	// the child tokens and the StateReceiver on Polygon, and the MRC20 native token events.
+	PoSBridge = builtin("pos_bridge.json")
+)
+
+// Builtins returns the built-in ABIs, in the order DefaultRegistry tries them.
+func Builtins() []*ABI {
+	return []*ABI{ERC20(), ERC721(), ERC1155(), PoSBridge()}
+}
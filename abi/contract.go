@@ -0,0 +1,161 @@
+package abi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/rafaribe/polygon-client/rpc"
+)
+
+// ErrEmptyResult is returned when a call of a method with outputs returns no data.
+var ErrEmptyResult = errors.New("empty call result, the address may not be a contract")
+
+// Caller executes calls against the state of a block, as *rpc.Client does.
+type Caller interface {
+	Call(ctx context.Context, msg rpc.CallMsg, block rpc.BlockTag) (rpc.Bytes, error)
+}
+
+// Contract reads the state of a deployed contract, encoding calls and decoding their results with its ABI.
+type Contract struct {
+	Address rpc.Address
+	ABI     *ABI
+	caller  Caller
+}
+
+// NewContract binds the ABI to the contract at address, calls being executed by caller.
+func NewContract(caller Caller, address rpc.Address, a *ABI) *Contract {
+	return &Contract{Address: address, ABI: a, caller: caller}
+}
+
+// CustomError is returned by Contract.Call when the call reverts with a custom error declared in the ABI.
+type CustomError struct {
+	Name string
+	Args []Value
+	// Revert is the error returned by the client
+	Revert *rpc.RevertError
+}
+
+func (e *CustomError) Error() string {
+	return "execution reverted: " + e.Name + FormatValue(e.Args)
+}
+
+func (e *CustomError) Unwrap() error {
+	return e.Revert
+}
+
+// DecodeRevert decodes the custom error the revert data encodes.
+// It returns ErrUnknownError when the ABI has no error with the data selector.
+func (a *ABI) DecodeRevert(data []byte) (*CustomError, error) {
+	e, ok := a.ErrorBySelector(data)
+	if !ok {
+		return nil, ErrUnknownError
+	}
+	args, err := e.Inputs.Unpack(data[4:])
+	if err != nil {
+		return nil, fmt.Errorf("error decoding %s error: %v", e.Name, err)
+	}
+	return &CustomError{Name: e.Name, Args: args}, nil
+}
+
+// Call calls the method with the given name and arguments against the state of the block and returns its outputs.
+// A revert with a custom error of the ABI is returned as a *CustomError, other reverts as the *rpc.RevertError of the client.
+func (c *Contract) Call(ctx context.Context, block rpc.BlockTag, method string, args ...interface{}) ([]Value, error) {
+	m, ok := c.ABI.Method(method)
+	if !ok {
+		return nil, fmt.Errorf("%w %s", ErrUnknownMethod, method)
+	}
+	input, err := m.Pack(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := c.caller.Call(ctx, rpc.CallMsg{To: &c.Address, Data: input}, block)
+	var revertErr *rpc.RevertError
+	if errors.As(err, &revertErr) {
+		if custom, decodeErr := c.ABI.DecodeRevert(revertErr.Data); decodeErr == nil {
+			custom.Revert = revertErr
+			return nil, custom
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error calling %s: %w", m.Name, err)
+	}
+	if len(output) == 0 && len(m.Outputs) > 0 {
+		return nil, fmt.Errorf("error calling %s at %s: %w", m.Name, c.Address, ErrEmptyResult)
+	}
+
+	values, err := m.Outputs.Unpack(output)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding %s output: %v", m.Name, err)
+	}
+	return values, nil
+}
+
+// call calls a method returning a single output and returns its value.
+func (c *Contract) call(ctx context.Context, block rpc.BlockTag, method string, args ...interface{}) (interface{}, error) {
+	values, err := c.Call(ctx, block, method, args...)
+	if err != nil {
+		return nil, err
+	}
+	if len(values) != 1 {
+		return nil, fmt.Errorf("expected a single %s output, got %d", method, len(values))
+	}
+	return values[0].Value, nil
+}
+
+// Token reads the state of an ERC-20 token.
+type Token struct {
+	*Contract
+}
+
+// NewToken binds the ERC-20 ABI to the token at address.
+func NewToken(caller Caller, address rpc.Address) *Token {
+	return &Token{Contract: NewContract(caller, address, ERC20())}
+}
+
+// BalanceOf returns the balance of the account at the block, in the smallest unit of the token.
+func (t *Token) BalanceOf(ctx context.Context, account rpc.Address, block rpc.BlockTag) (*big.Int, error) {
+	return t.callBigInt(ctx, block, "balanceOf", account)
+}
+
+// TotalSupply returns the total supply at the block, in the smallest unit of the token.
+func (t *Token) TotalSupply(ctx context.Context, block rpc.BlockTag) (*big.Int, error) {
+	return t.callBigInt(ctx, block, "totalSupply")
+}
+
+// Decimals returns the number of decimals of the token, 6 for USDC or 18 for WETH for instance.
+func (t *Token) Decimals(ctx context.Context, block rpc.BlockTag) (uint8, error) {
+	decimals, err := t.callBigInt(ctx, block, "decimals")
+	if err != nil {
+		return 0, err
+	}
+	return uint8(decimals.Uint64()), nil
+}
+
+// Symbol returns the symbol of the token.
+func (t *Token) Symbol(ctx context.Context, block rpc.BlockTag) (string, error) {
+	return t.callString(ctx, block, "symbol")
+}
+
+// Name returns the name of the token.
+func (t *Token) Name(ctx context.Context, block rpc.BlockTag) (string, error) {
+	return t.callString(ctx, block, "name")
+}
+
+func (t *Token) callBigInt(ctx context.Context, block rpc.BlockTag, method string, args ...interface{}) (*big.Int, error) {
+	v, err := t.call(ctx, block, method, args...)
+	if err != nil {
+		return nil, err
+	}
+	return v.(*big.Int), nil
+}
+
+func (t *Token) callString(ctx context.Context, block rpc.BlockTag, method string) (string, error) {
+	v, err := t.call(ctx, block, method)
+	if err != nil {
+		return "", err
+	}
+	return v.(string), nil
+}
@@ -0,0 +1,107 @@
+package abi
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/rafaribe/polygon-client/rpc"
+)
+
+// callerFunc adapts a function to the Caller interface.
+type callerFunc func(ctx context.Context, msg rpc.CallMsg, block rpc.BlockTag) (rpc.Bytes, error)
+
+func (f callerFunc) Call(ctx context.Context, msg rpc.CallMsg, block rpc.BlockTag) (rpc.Bytes, error) {
+	return f(ctx, msg, block)
+}
+
+func TestToken(t *testing.T) {
+	usdt, _ := rpc.HexToAddress("0xc2132d05d31c914a87c6611c10748aeb04b58e8f")
+	holder, _ := rpc.HexToAddress("0x5a52e96bacdabb82fd05763e25335261b270efcb")
+	block := rpc.BlockNumberTag(0x3a9f1c2)
+
+	balanceOf := hexData(t, "70a08231", "0000000000000000000000005a52e96bacdabb82fd05763e25335261b270efcb")
+	responses := map[string][]byte{
+		string(balanceOf):              hexData(t, "00000000000000000000000000000000000000000000000000000002540be400"),
+		string(hexData(t, "313ce567")): hexData(t, "0000000000000000000000000000000000000000000000000000000000000006"),
+		string(hexData(t, "95d89b41")): hexData(t,
+			"0000000000000000000000000000000000000000000000000000000000000020",
+			"0000000000000000000000000000000000000000000000000000000000000004",
+			"5553445400000000000000000000000000000000000000000000000000000000",
+		),
+	}
+	token := NewToken(callerFunc(func(ctx context.Context, msg rpc.CallMsg, tag rpc.BlockTag) (rpc.Bytes, error) {
+		if msg.To == nil || *msg.To != usdt {
+			t.Errorf("expected a call to %s, got %v", usdt, msg.To)
+		}
+		if tag != block {
+			t.Errorf("expected block %s, got %s", block, tag)
+		}
+		return responses[string(msg.Data)], nil
+	}), usdt)
+
+	balance, err := token.BalanceOf(context.Background(), holder, block)
+	if err != nil {
+		t.Fatalf("BalanceOf returned unexpected error: %v", err)
+	}
+	if balance.Cmp(big.NewInt(10_000_000_000)) != 0 {
+		t.Errorf("expected balance 10000000000, got %s", balance)
+	}
+	decimals, err := token.Decimals(context.Background(), block)
+	if err != nil {
+		t.Fatalf("Decimals returned unexpected error: %v", err)
+	}
+	if decimals != 6 {
+		t.Errorf("expected 6 decimals, got %d", decimals)
+	}
+	symbol, err := token.Symbol(context.Background(), block)
+	if err != nil {
+		t.Fatalf("Symbol returned unexpected error: %v", err)
+	}
+	if symbol != "USDT" {
+		t.Errorf("expected symbol USDT, got %s", symbol)
+	}
+
+	// No response is registered for totalSupply, as if the address held no contract
+	if _, err := token.TotalSupply(context.Background(), block); !errors.Is(err, ErrEmptyResult) {
+		t.Errorf("expected ErrEmptyResult, got %v", err)
+	}
+}
+
+func TestContractCallCustomError(t *testing.T) {
+	a := mustParse(t, `[
+		{"type":"function","name":"withdraw","inputs":[{"name":"amount","type":"uint256"}],"outputs":[]},
+		{"type":"error","name":"InsufficientBalance","inputs":[{"name":"available","type":"uint256"},{"name":"required","type":"uint256"}]}
+	]`)
+	revertData := append(keccak256([]byte("InsufficientBalance(uint256,uint256)"))[:4], hexData(t,
+		"0000000000000000000000000000000000000000000000000000000000000001",
+		"0000000000000000000000000000000000000000000000000000000000000002",
+	)...)
+
+	var vault rpc.Address
+	contract := NewContract(callerFunc(func(ctx context.Context, msg rpc.CallMsg, block rpc.BlockTag) (rpc.Bytes, error) {
+		expected, _ := a.Methods[0].Pack(2)
+		if !bytes.Equal(msg.Data, expected) {
+			t.Errorf("expected input %x, got %x", expected, msg.Data)
+		}
+		return nil, &rpc.RevertError{Data: revertData, Err: &rpc.RPCError{Code: rpc.CodeExecutionReverted, Message: "execution reverted"}}
+	}), vault, a)
+
+	_, err := contract.Call(context.Background(), rpc.LatestBlock, "withdraw", 2)
+	var customErr *CustomError
+	if !errors.As(err, &customErr) {
+		t.Fatalf("expected a CustomError, got %v", err)
+	}
+	if customErr.Error() != "execution reverted: InsufficientBalance(available: 1, required: 2)" {
+		t.Errorf("unexpected error message %q", customErr.Error())
+	}
+	if !errors.Is(err, rpc.ErrExecutionReverted) {
+		t.Errorf("expected the error to match rpc.ErrExecutionReverted")
+	}
+
+	if _, err := contract.Call(context.Background(), rpc.LatestBlock, "deposit"); !errors.Is(err, ErrUnknownMethod) {
+		t.Errorf("expected ErrUnknownMethod, got %v", err)
+	}
+}
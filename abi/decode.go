@@ -0,0 +1,217 @@
+package abi
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/rafaribe/polygon-client/rpc"
+)
+
+// Value is a decoded argument.
+// Integers decode to *big.Int, addresses to rpc.Address, bool to bool, fixed and dynamic bytes to []byte, strings to string,
+// arrays and slices to []interface{} and tuples to []Value.
+type Value struct {
+	Name  string
+	Type  Type
+	Value interface{}
+}
+
+// String formats the value for humans, see FormatValue.
+func (v Value) String() string {
+	return FormatValue(v.Value)
+}
+
+// FormatValue formats a decoded value for humans: integers in decimal, addresses and bytes in 0x prefixed hex,
+// arrays in brackets and tuples in parentheses with their field names.
+func FormatValue(v interface{}) string {
+	switch v := v.(type) {
+	case *big.Int:
+		return v.String()
+	case []byte:
+		return "0x" + hex.EncodeToString(v)
+	case string:
+		return v
+	case []interface{}:
+		elems := make([]string, len(v))
+		for i, elem := range v {
+			elems[i] = FormatValue(elem)
+		}
+		return "[" + strings.Join(elems, ", ") + "]"
+	case []Value:
+		fields := make([]string, len(v))
+		for i, field := range v {
+			fields[i] = FormatValue(field.Value)
+			if field.Name != "" {
+				fields[i] = field.Name + ": " + fields[i]
+			}
+		}
+		return "(" + strings.Join(fields, ", ") + ")"
+	}
+	return fmt.Sprint(v)
+}
+
+// Unpack decodes ABI encoded values of the arguments, as found in transaction input after the selector or in log data.
+func (args Arguments) Unpack(data []byte) ([]Value, error) {
+	types := make([]Type, len(args))
+	for i, arg := range args {
+		types[i] = arg.Type
+	}
+	decoded, err := decodeTuple(types, data)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]Value, len(args))
+	for i, arg := range args {
+		values[i] = Value{Name: arg.Name, Type: arg.Type, Value: decoded[i]}
+	}
+	return values, nil
+}
+
+// decodeTuple decodes consecutive values of the given types, data starting at the head of the first one.
+// Static values are decoded in place, dynamic ones are found at the offset, relative to the start of data, held in their head.
+func decodeTuple(types []Type, data []byte) ([]interface{}, error) {
+	values := make([]interface{}, len(types))
+	pos := 0
+	for i, t := range types {
+		if pos+t.headSize() > len(data) {
+			return nil, fmt.Errorf("data too short for %s at offset %d", t, pos)
+		}
+		at := data[pos:]
+		if t.dynamic() {
+			offset, err := readLength(data[pos:], len(data))
+			if err != nil {
+				return nil, fmt.Errorf("invalid offset of %s: %v", t, err)
+			}
+			at = data[offset:]
+		}
+		v, err := decodeValue(t, at)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = v
+		pos += t.headSize()
+	}
+	return values, nil
+}
+
+// decodeValue decodes a value of the given type, data starting where the value is encoded.
+func decodeValue(t Type, data []byte) (interface{}, error) {
+	switch t.Kind {
+	case UintKind, IntKind, AddressKind, BoolKind, FixedBytesKind, FunctionKind:
+		if len(data) < wordSize {
+			return nil, fmt.Errorf("data too short for %s", t)
+		}
+		return decodeWord(t, data[:wordSize])
+	case BytesKind, StringKind:
+		n, err := readLength(data, len(data)-wordSize)
+		if err != nil {
+			return nil, fmt.Errorf("invalid length of %s: %v", t, err)
+		}
+		b := make([]byte, n)
+		copy(b, data[wordSize:wordSize+n])
+		if t.Kind == StringKind {
+			return string(b), nil
+		}
+		return b, nil
+	case SliceKind:
+		// Every element takes at least a word, which bounds the length by the size of the data
+		n, err := readLength(data, (len(data)-wordSize)/wordSize)
+		if err != nil {
+			return nil, fmt.Errorf("invalid length of %s: %v", t, err)
+		}
+		return decodeElems(*t.Elem, n, data[wordSize:])
+	case ArrayKind:
+		return decodeElems(*t.Elem, t.Size, data)
+	case TupleKind:
+		types := make([]Type, len(t.Fields))
+		for i, field := range t.Fields {
+			types[i] = field.Type
+		}
+		decoded, err := decodeTuple(types, data)
+		if err != nil {
+			return nil, err
+		}
+		fields := make([]Value, len(t.Fields))
+		for i, field := range t.Fields {
+			fields[i] = Value{Name: field.Name, Type: field.Type, Value: decoded[i]}
+		}
+		return fields, nil
+	}
+	return nil, fmt.Errorf("unsupported type %s", t)
+}
+
+// decodeElems decodes the n elements of an array or a slice, which are encoded as a tuple.
+func decodeElems(elem Type, n int, data []byte) ([]interface{}, error) {
+	types := make([]Type, n)
+	for i := range types {
+		types[i] = elem
+	}
+	return decodeTuple(types, data)
+}
+
+// decodeWord decodes a value of a type that fits in a single word.
+func decodeWord(t Type, word []byte) (interface{}, error) {
+	switch t.Kind {
+	case UintKind:
+		v := new(big.Int).SetBytes(word)
+		if v.BitLen() > t.Size {
+			return nil, fmt.Errorf("value out of range for %s", t)
+		}
+		return v, nil
+	case IntKind:
+		v := new(big.Int).SetBytes(word)
+		if word[0]&0x80 != 0 {
+			v.Sub(v, new(big.Int).Lsh(big.NewInt(1), 256))
+		}
+		// The value must be sign extended from its size
+		bound := new(big.Int).Lsh(big.NewInt(1), uint(t.Size-1))
+		if v.CmpAbs(bound) > 0 || (v.Sign() > 0 && v.Cmp(bound) == 0) {
+			return nil, fmt.Errorf("value out of range for %s", t)
+		}
+		return v, nil
+	case AddressKind:
+		if !isZero(word[:wordSize-rpc.AddressLength]) {
+			return nil, fmt.Errorf("invalid address padding")
+		}
+		var a rpc.Address
+		copy(a[:], word[wordSize-rpc.AddressLength:])
+		return a, nil
+	case BoolKind:
+		if !isZero(word[:wordSize-1]) || word[wordSize-1] > 1 {
+			return nil, fmt.Errorf("invalid bool")
+		}
+		return word[wordSize-1] == 1, nil
+	case FixedBytesKind, FunctionKind:
+		if !isZero(word[t.Size:]) {
+			return nil, fmt.Errorf("invalid %s padding", t)
+		}
+		b := make([]byte, t.Size)
+		copy(b, word)
+		return b, nil
+	}
+	return nil, fmt.Errorf("unsupported type %s", t)
+}
+
+// readLength reads a length or an offset from the first word of data, which must not exceed limit.
+func readLength(data []byte, limit int) (int, error) {
+	if len(data) < wordSize {
+		return 0, fmt.Errorf("data too short")
+	}
+	v := new(big.Int).SetBytes(data[:wordSize])
+	if limit < 0 || !v.IsInt64() || v.Int64() > int64(limit) {
+		return 0, fmt.Errorf("%s is larger than the data allows", v)
+	}
+	return int(v.Int64()), nil
+}
+
+func isZero(b []byte) bool {
+	for _, c := range b {
+		if c != 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,158 @@
+package abi
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+// hexData joins the words of an encoding, as laid out in the Solidity ABI specification examples.
+func hexData(t *testing.T, words ...string) []byte {
+	t.Helper()
+	data, err := hex.DecodeString(strings.Join(words, ""))
+	if err != nil {
+		t.Fatalf("invalid hex data: %v", err)
+	}
+	return data
+}
+
+func mustParse(t *testing.T, abiJSON string) *ABI {
+	t.Helper()
+	a, err := Parse([]byte(abiJSON))
+	if err != nil {
+		t.Fatalf("Parse returned unexpected error: %v", err)
+	}
+	return a
+}
+
+// callVector is an encoded call along with its decoded method and arguments.
+type callVector struct {
+	name     string
+	abi      string
+	input    []byte
+	method   string
+	expected string
+}
+
+// callVectors returns calls encoded as in the Solidity ABI specification examples.
+func callVectors(t *testing.T) []callVector {
+	return []callVector{
+		{
+			name:   "static and dynamic arguments",
+			abi:    `[{"type":"function","name":"f","inputs":[{"name":"a","type":"uint256"},{"name":"b","type":"uint32[]"},{"name":"c","type":"bytes10"},{"name":"d","type":"bytes"}]}]`,
+			method: "f(uint256,uint32[],bytes10,bytes)",
+			input: hexData(t,
+				"8be65246",
+				"0000000000000000000000000000000000000000000000000000000000000123",
+				"0000000000000000000000000000000000000000000000000000000000000080",
+				"3132333435363738393000000000000000000000000000000000000000000000",
+				"00000000000000000000000000000000000000000000000000000000000000e0",
+				"0000000000000000000000000000000000000000000000000000000000000002",
+				"0000000000000000000000000000000000000000000000000000000000000456",
+				"0000000000000000000000000000000000000000000000000000000000000789",
+				"000000000000000000000000000000000000000000000000000000000000000d",
+				"48656c6c6f2c20776f726c642100000000000000000000000000000000000000",
+			),
+			expected: "a=291 b=[1110, 1929] c=0x31323334353637383930 d=0x48656c6c6f2c20776f726c6421",
+		},
+		{
+			name:   "nested dynamic arrays",
+			abi:    `[{"type":"function","name":"g","inputs":[{"name":"a","type":"uint256[][]"},{"name":"b","type":"string[]"}]}]`,
+			method: "g(uint256[][],string[])",
+			input: hexData(t,
+				"2289b18c",
+				"0000000000000000000000000000000000000000000000000000000000000040",
+				"0000000000000000000000000000000000000000000000000000000000000140",
+				"0000000000000000000000000000000000000000000000000000000000000002",
+				"0000000000000000000000000000000000000000000000000000000000000040",
+				"00000000000000000000000000000000000000000000000000000000000000a0",
+				"0000000000000000000000000000000000000000000000000000000000000002",
+				"0000000000000000000000000000000000000000000000000000000000000001",
+				"0000000000000000000000000000000000000000000000000000000000000002",
+				"0000000000000000000000000000000000000000000000000000000000000001",
+				"0000000000000000000000000000000000000000000000000000000000000003",
+				"0000000000000000000000000000000000000000000000000000000000000003",
+				"0000000000000000000000000000000000000000000000000000000000000060",
+				"00000000000000000000000000000000000000000000000000000000000000a0",
+				"00000000000000000000000000000000000000000000000000000000000000e0",
+				"0000000000000000000000000000000000000000000000000000000000000003",
+				"6f6e650000000000000000000000000000000000000000000000000000000000",
+				"0000000000000000000000000000000000000000000000000000000000000003",
+				"74776f0000000000000000000000000000000000000000000000000000000000",
+				"0000000000000000000000000000000000000000000000000000000000000005",
+				"7468726565000000000000000000000000000000000000000000000000000000",
+			),
+			expected: "a=[[1, 2], [3]] b=[one, two, three]",
+		},
+		{
+			name:   "dynamic tuple and negative integer",
+			abi:    `[{"type":"function","name":"h","inputs":[{"name":"order","type":"tuple","components":[{"name":"id","type":"uint256"},{"name":"note","type":"string"}]},{"name":"delta","type":"int8"}]}]`,
+			method: "h((uint256,string),int8)",
+			input: hexData(t,
+				hex.EncodeToString(keccak256([]byte("h((uint256,string),int8)"))[:4]),
+				"0000000000000000000000000000000000000000000000000000000000000040",
+				"ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
+				"0000000000000000000000000000000000000000000000000000000000000007",
+				"0000000000000000000000000000000000000000000000000000000000000040",
+				"0000000000000000000000000000000000000000000000000000000000000002",
+				"6869000000000000000000000000000000000000000000000000000000000000",
+			),
+			expected: "order=(id: 7, note: hi) delta=-1",
+		},
+	}
+}
+
+func TestDecodeInput(t *testing.T) {
+	for _, tt := range callVectors(t) {
+		t.Run(tt.name, func(t *testing.T) {
+			call, err := mustParse(t, tt.abi).DecodeInput(tt.input)
+			if err != nil {
+				t.Fatalf("DecodeInput returned unexpected error: %v", err)
+			}
+			if call.Method.Signature() != tt.method {
+				t.Errorf("expected method %s, got %s", tt.method, call.Method.Signature())
+			}
+			args := make([]string, len(call.Args))
+			for i, arg := range call.Args {
+				args[i] = arg.Name + "=" + arg.String()
+			}
+			if got := strings.Join(args, " "); got != tt.expected {
+				t.Errorf("expected arguments %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestDecodeRejectsMalformedData(t *testing.T) {
+	a := mustParse(t, `[{"type":"function","name":"f","inputs":[{"name":"a","type":"uint8"},{"name":"b","type":"bytes"}]}]`)
+	selector := hex.EncodeToString(keccak256([]byte("f(uint8,bytes)"))[:4])
+
+	tests := []struct {
+		name  string
+		input []byte
+	}{
+		{"truncated head", hexData(t, selector, "0000000000000000000000000000000000000000000000000000000000000001")},
+		{"offset out of range", hexData(t, selector,
+			"0000000000000000000000000000000000000000000000000000000000000001",
+			"00000000000000000000000000000000000000000000000000000000000000ff",
+		)},
+		{"length out of range", hexData(t, selector,
+			"0000000000000000000000000000000000000000000000000000000000000001",
+			"0000000000000000000000000000000000000000000000000000000000000040",
+			"00000000000000000000000000000000000000000000000000000000ffffffff",
+		)},
+		{"uint8 overflow", hexData(t, selector,
+			"0000000000000000000000000000000000000000000000000000000000000100",
+			"0000000000000000000000000000000000000000000000000000000000000040",
+			"0000000000000000000000000000000000000000000000000000000000000000",
+		)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := a.DecodeInput(tt.input); err == nil {
+				t.Errorf("expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,229 @@
+package abi
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+
+	"github.com/rafaribe/polygon-client/rpc"
+)
+
+// Pack ABI encodes values of the arguments, in order, as found in transaction input after the selector.
+// Integers are given as *big.Int or any Go integer, addresses as rpc.Address, fixed bytes as a byte array or slice of the
+// exact size, bytes as a byte slice, arrays and slices as any Go array or slice and tuples as []interface{} or []Value
+// holding their fields in order.
+func (args Arguments) Pack(values ...interface{}) ([]byte, error) {
+	if len(values) != len(args) {
+		return nil, fmt.Errorf("expected %d values, got %d", len(args), len(values))
+	}
+	types := make([]Type, len(args))
+	for i, arg := range args {
+		types[i] = arg.Type
+	}
+	return encodeTuple(types, values)
+}
+
+// Pack encodes a call of the method with the given arguments, its selector followed by the packed arguments.
+func (m *Method) Pack(args ...interface{}) ([]byte, error) {
+	packed, err := m.Inputs.Pack(args...)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding %s input: %v", m.Name, err)
+	}
+	return append(append([]byte{}, m.Selector[:]...), packed...), nil
+}
+
+// encodeTuple encodes consecutive values of the given types, static ones in the head and dynamic ones in the tail,
+// behind an offset relative to the start of the encoding.
+func encodeTuple(types []Type, values []interface{}) ([]byte, error) {
+	if len(values) != len(types) {
+		return nil, fmt.Errorf("expected %d values, got %d", len(types), len(values))
+	}
+	headSize := 0
+	for _, t := range types {
+		headSize += t.headSize()
+	}
+
+	head := make([]byte, 0, headSize)
+	var tail []byte
+	for i, t := range types {
+		encoded, err := encodeValue(t, values[i])
+		if err != nil {
+			return nil, err
+		}
+		if t.dynamic() {
+			head = append(head, encodeLength(headSize+len(tail))...)
+			tail = append(tail, encoded...)
+		} else {
+			head = append(head, encoded...)
+		}
+	}
+	return append(head, tail...), nil
+}
+
+// encodeValue encodes a value of the given type.
+func encodeValue(t Type, v interface{}) ([]byte, error) {
+	switch t.Kind {
+	case UintKind, IntKind:
+		i, ok := toBigInt(v)
+		if !ok {
+			return nil, fmt.Errorf("invalid %s value of type %T", t, v)
+		}
+		return encodeInt(t, i)
+	case AddressKind:
+		a, ok := v.(rpc.Address)
+		if !ok {
+			return nil, fmt.Errorf("invalid %s value of type %T", t, v)
+		}
+		word := make([]byte, wordSize)
+		copy(word[wordSize-rpc.AddressLength:], a[:])
+		return word, nil
+	case BoolKind:
+		b, ok := v.(bool)
+		if !ok {
+			return nil, fmt.Errorf("invalid %s value of type %T", t, v)
+		}
+		word := make([]byte, wordSize)
+		if b {
+			word[wordSize-1] = 1
+		}
+		return word, nil
+	case FixedBytesKind, FunctionKind:
+		b, ok := toBytes(v)
+		if !ok || len(b) != t.Size {
+			return nil, fmt.Errorf("invalid %s value of type %T", t, v)
+		}
+		return padRight(b), nil
+	case BytesKind, StringKind:
+		b, ok := toBytes(v)
+		if s, isString := v.(string); isString && t.Kind == StringKind {
+			b, ok = []byte(s), true
+		}
+		if !ok {
+			return nil, fmt.Errorf("invalid %s value of type %T", t, v)
+		}
+		return append(encodeLength(len(b)), padRight(b)...), nil
+	case SliceKind:
+		elems, ok := toElems(v)
+		if !ok {
+			return nil, fmt.Errorf("invalid %s value of type %T", t, v)
+		}
+		encoded, err := encodeElems(*t.Elem, elems)
+		if err != nil {
+			return nil, err
+		}
+		return append(encodeLength(len(elems)), encoded...), nil
+	case ArrayKind:
+		elems, ok := toElems(v)
+		if !ok || len(elems) != t.Size {
+			return nil, fmt.Errorf("invalid %s value of type %T", t, v)
+		}
+		return encodeElems(*t.Elem, elems)
+	case TupleKind:
+		fields, ok := toFields(v)
+		if !ok || len(fields) != len(t.Fields) {
+			return nil, fmt.Errorf("invalid %s value of type %T", t, v)
+		}
+		types := make([]Type, len(t.Fields))
+		for i, field := range t.Fields {
+			types[i] = field.Type
+		}
+		return encodeTuple(types, fields)
+	}
+	return nil, fmt.Errorf("unsupported type %s", t)
+}
+
+// encodeElems encodes the elements of an array or a slice, which are encoded as a tuple.
+func encodeElems(elem Type, elems []interface{}) ([]byte, error) {
+	types := make([]Type, len(elems))
+	for i := range types {
+		types[i] = elem
+	}
+	return encodeTuple(types, elems)
+}
+
+// encodeInt encodes an integer in a word, negative ones in two's complement, after checking it fits the type.
+func encodeInt(t Type, i *big.Int) ([]byte, error) {
+	if t.Kind == UintKind {
+		if i.Sign() < 0 || i.BitLen() > t.Size {
+			return nil, fmt.Errorf("value %s out of range for %s", i, t)
+		}
+		return i.FillBytes(make([]byte, wordSize)), nil
+	}
+	bound := new(big.Int).Lsh(big.NewInt(1), uint(t.Size-1))
+	if i.Cmp(bound) >= 0 || i.Cmp(new(big.Int).Neg(bound)) < 0 {
+		return nil, fmt.Errorf("value %s out of range for %s", i, t)
+	}
+	if i.Sign() < 0 {
+		i = new(big.Int).Add(i, new(big.Int).Lsh(big.NewInt(1), 8*wordSize))
+	}
+	return i.FillBytes(make([]byte, wordSize)), nil
+}
+
+// encodeLength encodes a length or an offset in a word.
+func encodeLength(n int) []byte {
+	return new(big.Int).SetInt64(int64(n)).FillBytes(make([]byte, wordSize))
+}
+
+// padRight pads b with zeros to a multiple of the word size.
+func padRight(b []byte) []byte {
+	padded := make([]byte, (len(b)+wordSize-1)/wordSize*wordSize)
+	copy(padded, b)
+	return padded
+}
+
+// toBigInt converts a *big.Int, an *rpc.BigInt or a Go integer.
+func toBigInt(v interface{}) (*big.Int, bool) {
+	switch v := v.(type) {
+	case *big.Int:
+		return v, v != nil
+	case *rpc.BigInt:
+		return v.ToInt(), v != nil
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return big.NewInt(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return new(big.Int).SetUint64(rv.Uint()), true
+	}
+	return nil, false
+}
+
+// toBytes converts a byte slice or a byte array, such as an rpc.Hash.
+func toBytes(v interface{}) ([]byte, bool) {
+	rv := reflect.ValueOf(v)
+	if (rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array) || rv.Type().Elem().Kind() != reflect.Uint8 {
+		return nil, false
+	}
+	b := make([]byte, rv.Len())
+	reflect.Copy(reflect.ValueOf(b), rv)
+	return b, true
+}
+
+// toElems converts any Go slice or array to its elements.
+func toElems(v interface{}) ([]interface{}, bool) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, false
+	}
+	elems := make([]interface{}, rv.Len())
+	for i := range elems {
+		elems[i] = rv.Index(i).Interface()
+	}
+	return elems, true
+}
+
+// toFields converts the fields of a tuple, given in order as []interface{} or as decoded []Value.
+func toFields(v interface{}) ([]interface{}, bool) {
+	switch v := v.(type) {
+	case []interface{}:
+		return v, true
+	case []Value:
+		fields := make([]interface{}, len(v))
+		for i, field := range v {
+			fields[i] = field.Value
+		}
+		return fields, true
+	}
+	return nil, false
+}
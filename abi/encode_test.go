@@ -0,0 +1,83 @@
+package abi
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/rafaribe/polygon-client/rpc"
+)
+
+func TestPackRoundTrip(t *testing.T) {
+	for _, tt := range callVectors(t) {
+		t.Run(tt.name, func(t *testing.T) {
+			call, err := mustParse(t, tt.abi).DecodeInput(tt.input)
+			if err != nil {
+				t.Fatalf("DecodeInput returned unexpected error: %v", err)
+			}
+			args := make([]interface{}, len(call.Args))
+			for i, arg := range call.Args {
+				args[i] = arg.Value
+			}
+
+			input, err := call.Method.Pack(args...)
+			if err != nil {
+				t.Fatalf("Pack returned unexpected error: %v", err)
+			}
+			if !bytes.Equal(input, tt.input) {
+				t.Errorf("expected %x, got %x", tt.input, input)
+			}
+		})
+	}
+}
+
+func TestPackGoValues(t *testing.T) {
+	vectors := callVectors(t)
+	tests := []struct {
+		vector callVector
+		args   []interface{}
+	}{
+		{vectors[0], []interface{}{0x123, []uint32{0x456, 0x789}, []byte("1234567890"), []byte("Hello, world!")}},
+		{vectors[1], []interface{}{[][]*big.Int{{big.NewInt(1), big.NewInt(2)}, {big.NewInt(3)}}, []string{"one", "two", "three"}}},
+		{vectors[2], []interface{}{[]interface{}{uint64(7), "hi"}, int8(-1)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.vector.name, func(t *testing.T) {
+			a := mustParse(t, tt.vector.abi)
+			input, err := a.Methods[0].Pack(tt.args...)
+			if err != nil {
+				t.Fatalf("Pack returned unexpected error: %v", err)
+			}
+			if !bytes.Equal(input, tt.vector.input) {
+				t.Errorf("expected %x, got %x", tt.vector.input, input)
+			}
+		})
+	}
+}
+
+func TestPackRejectsInvalidValues(t *testing.T) {
+	a := mustParse(t, `[{"type":"function","name":"f","inputs":[{"name":"a","type":"uint8"},{"name":"b","type":"int16"},{"name":"c","type":"address"},{"name":"d","type":"bytes4"}]}]`)
+	m := a.Methods[0]
+	var token rpc.Address
+
+	tests := []struct {
+		name string
+		args []interface{}
+	}{
+		{"missing argument", []interface{}{1, 2, token}},
+		{"uint8 overflow", []interface{}{256, 2, token, [4]byte{}}},
+		{"negative uint", []interface{}{-1, 2, token, [4]byte{}}},
+		{"int16 underflow", []interface{}{1, -32769, token, [4]byte{}}},
+		{"address as string", []interface{}{1, 2, "0xc2132d05d31c914a87c6611c10748aeb04b58e8f", [4]byte{}}},
+		{"fixed bytes of the wrong size", []interface{}{1, 2, token, []byte{1, 2, 3}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := m.Pack(tt.args...); err == nil {
+				t.Errorf("expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package abi
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rafaribe/polygon-client/rpc"
+)
+
+// Registry decodes the activity of many contracts, with ABIs bound to a contract address or tried for every contract.
+// It must not be modified while decoding, decoding concurrently is safe.
+type Registry struct {
+	contracts map[rpc.Address][]*ABI
+	generic   []*ABI
+}
+
+// NewRegistry creates a registry trying the given ABIs for every contract, in order.
+func NewRegistry(abis ...*ABI) *Registry {
+	return &Registry{contracts: make(map[rpc.Address][]*ABI), generic: abis}
+}
+
+// DefaultRegistry creates a registry of the built-in ABIs.
+func DefaultRegistry() *Registry {
+	return NewRegistry(Builtins()...)
+}
+
+// Add adds an ABI tried for every contract, after the ones added before.
+func (r *Registry) Add(a *ABI) {
+	r.generic = append(r.generic, a)
+}
+
+// AddContract adds an ABI only tried for the contract at address, before the ones tried for every contract.
+func (r *Registry) AddContract(address rpc.Address, a *ABI) {
+	r.contracts[address] = append(r.contracts[address], a)
+}
+
+// LoadDir adds every JSON ABI file of dir. Files named after a contract address, e.g. 0xc2132d05d31c914a87c6611c10748aeb04b58e8f.json,
+// are only tried for that contract, the others for every contract.
+func (r *Registry) LoadDir(dir string) error {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return fmt.Errorf("error listing ABI files: %v", err)
+	}
+	for _, path := range paths {
+		a, err := LoadFile(path)
+		if err != nil {
+			return err
+		}
+		if address, err := rpc.HexToAddress(strings.TrimSuffix(filepath.Base(path), ".json")); err == nil {
+			r.AddContract(address, a)
+		} else {
+			r.Add(a)
+		}
+	}
+	if len(paths) == 0 {
+		if _, err := os.Stat(dir); err != nil {
+			return fmt.Errorf("error reading ABI directory: %v", err)
+		}
+	}
+	return nil
+}
+
+// candidates returns the ABIs tried for the contract at address, in order.
+func (r *Registry) candidates(address rpc.Address) []*ABI {
+	contract := r.contracts[address]
+	candidates := make([]*ABI, 0, len(contract)+len(r.generic))
+	return append(append(candidates, contract...), r.generic...)
+}
+
+// DecodeInput decodes the input of a transaction sent to the contract at address.
+// It returns ErrUnknownMethod when no ABI has a method with the input selector.
+func (r *Registry) DecodeInput(address rpc.Address, input []byte) (*Call, error) {
+	var decodeErr error
+	for _, a := range r.candidates(address) {
+		call, err := a.DecodeInput(input)
+		if err == nil {
+			return call, nil
+		}
+		if !errors.Is(err, ErrUnknownMethod) {
+			decodeErr = err
+		}
+	}
+	if decodeErr != nil {
+		return nil, decodeErr
+	}
+	return nil, ErrUnknownMethod
+}
+
+// DecodeLog decodes a log with the ABIs of the contract that emitted it.
+// It returns ErrUnknownEvent when no ABI has an event matching the log topics.
+func (r *Registry) DecodeLog(log *rpc.Log) (*DecodedLog, error) {
+	var decodeErr error
+	for _, a := range r.candidates(log.Address) {
+		decoded, err := a.DecodeLog(log)
+		if err == nil {
+			return decoded, nil
+		}
+		if !errors.Is(err, ErrUnknownEvent) {
+			decodeErr = err
+		}
+	}
+	if decodeErr != nil {
+		return nil, decodeErr
+	}
+	return nil, ErrUnknownEvent
+}
@@ -0,0 +1,174 @@
+package abi
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Kind is the family of an ABI type.
+type Kind int
+
+const (
+	UintKind Kind = iota
+	IntKind
+	AddressKind
+	BoolKind
+	// FixedBytesKind is bytes1 to bytes32
+	FixedBytesKind
+	BytesKind
+	StringKind
+	// SliceKind is a variable length array, T[]
+	SliceKind
+	// ArrayKind is a fixed length array, T[k]
+	ArrayKind
+	TupleKind
+	// FunctionKind is an address followed by a function selector, encoded like bytes24
+	FunctionKind
+)
+
+// Type is a parsed ABI type.
+type Type struct {
+	Kind Kind
+	// Size is the number of bits of integers, of bytes of fixed bytes and functions and of elements of fixed length arrays
+	Size int
+	// Elem is the element type of slices and arrays
+	Elem *Type
+	// Fields are the components of tuples
+	Fields []Argument
+}
+
+// ParseType parses a type as written in a JSON ABI, components describing the fields of tuple types.
+func ParseType(s string, components []Argument) (Type, error) {
+	if strings.HasSuffix(s, "]") {
+		open := strings.LastIndexByte(s, '[')
+		if open < 0 {
+			return Type{}, fmt.Errorf("invalid type %q", s)
+		}
+		elem, err := ParseType(s[:open], components)
+		if err != nil {
+			return Type{}, err
+		}
+		if open+1 == len(s)-1 {
+			return Type{Kind: SliceKind, Elem: &elem}, nil
+		}
+		n, err := strconv.Atoi(s[open+1 : len(s)-1])
+		if err != nil || n <= 0 {
+			return Type{}, fmt.Errorf("invalid array length in type %q", s)
+		}
+		return Type{Kind: ArrayKind, Size: n, Elem: &elem}, nil
+	}
+
+	switch {
+	case s == "address":
+		return Type{Kind: AddressKind}, nil
+	case s == "bool":
+		return Type{Kind: BoolKind}, nil
+	case s == "string":
+		return Type{Kind: StringKind}, nil
+	case s == "bytes":
+		return Type{Kind: BytesKind}, nil
+	case s == "tuple":
+		if len(components) == 0 {
+			return Type{}, fmt.Errorf("tuple type without components")
+		}
+		return Type{Kind: TupleKind, Fields: components}, nil
+	case s == "function":
+		return Type{Kind: FunctionKind, Size: 24}, nil
+	case strings.HasPrefix(s, "bytes"):
+		n, err := strconv.Atoi(s[len("bytes"):])
+		if err != nil || n < 1 || n > 32 {
+			return Type{}, fmt.Errorf("invalid type %q", s)
+		}
+		return Type{Kind: FixedBytesKind, Size: n}, nil
+	case strings.HasPrefix(s, "uint"):
+		bits, err := intBits(s[len("uint"):])
+		if err != nil {
+			return Type{}, fmt.Errorf("invalid type %q: %v", s, err)
+		}
+		return Type{Kind: UintKind, Size: bits}, nil
+	case strings.HasPrefix(s, "int"):
+		bits, err := intBits(s[len("int"):])
+		if err != nil {
+			return Type{}, fmt.Errorf("invalid type %q: %v", s, err)
+		}
+		return Type{Kind: IntKind, Size: bits}, nil
+	}
+	return Type{}, fmt.Errorf("unsupported type %q", s)
+}
+
+// intBits parses the size suffix of an integer type, uint and int being aliases of uint256 and int256.
+func intBits(suffix string) (int, error) {
+	if suffix == "" {
+		return 256, nil
+	}
+	bits, err := strconv.Atoi(suffix)
+	if err != nil || bits < 8 || bits > 256 || bits%8 != 0 {
+		return 0, fmt.Errorf("size must be a multiple of 8 between 8 and 256")
+	}
+	return bits, nil
+}
+
+// String returns the canonical form of the type, as used in method and event signatures.
+func (t Type) String() string {
+	switch t.Kind {
+	case UintKind:
+		return "uint" + strconv.Itoa(t.Size)
+	case IntKind:
+		return "int" + strconv.Itoa(t.Size)
+	case AddressKind:
+		return "address"
+	case BoolKind:
+		return "bool"
+	case FixedBytesKind:
+		return "bytes" + strconv.Itoa(t.Size)
+	case BytesKind:
+		return "bytes"
+	case StringKind:
+		return "string"
+	case SliceKind:
+		return t.Elem.String() + "[]"
+	case ArrayKind:
+		return t.Elem.String() + "[" + strconv.Itoa(t.Size) + "]"
+	case TupleKind:
+		return "(" + Arguments(t.Fields).types() + ")"
+	case FunctionKind:
+		return "function"
+	}
+	return "unknown"
+}
+
+// dynamic reports whether values of the type are encoded out of place, behind an offset.
+func (t Type) dynamic() bool {
+	switch t.Kind {
+	case BytesKind, StringKind, SliceKind:
+		return true
+	case ArrayKind:
+		return t.Elem.dynamic()
+	case TupleKind:
+		for _, field := range t.Fields {
+			if field.Type.dynamic() {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// headSize returns the number of bytes the type takes in the head of its enclosing tuple.
+func (t Type) headSize() int {
+	if t.dynamic() {
+		return wordSize
+	}
+	switch t.Kind {
+	case ArrayKind:
+		return t.Size * t.Elem.headSize()
+	case TupleKind:
+		size := 0
+		for _, field := range t.Fields {
+			size += field.Type.headSize()
+		}
+		return size
+	}
+	return wordSize
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/rafaribe/polygon-client/abi"
+	"github.com/rafaribe/polygon-client/rpc"
+)
+
+// activityLogger logs the decoded contract calls and events of every processed block.
+type activityLogger struct {
+	registry *abi.Registry
+	receipts func(ctx context.Context, number uint64) ([]*rpc.Receipt, error)
+}
+
+// logBlock logs the transactions of the block whose input a known method decodes, when the block has full transactions,
+// and the logs of its receipts a known event decodes. Calls and events no ABI knows are skipped.
+func (a *activityLogger) logBlock(ctx context.Context, block *rpc.Block) error {
+	number := block.Number.Uint64()
+	if txs, ok := block.Transactions.Full(); ok {
+		for _, tx := range txs {
+			if tx.To == nil || len(tx.Input) < 4 {
+				continue
+			}
+			call, err := a.registry.DecodeInput(*tx.To, tx.Input)
+			if errors.Is(err, abi.ErrUnknownMethod) {
+				continue
+			}
+			if err != nil {
+				slog.Debug("error decoding transaction input", "block_number", number, "tx_hash", tx.Hash.String(), "error", err)
+				continue
+			}
+			slog.Info("contract call", "block_number", number, "tx_hash", tx.Hash.String(), "contract", tx.To.String(),
+				"method", call.Method.Name, argsAttr(call.Args))
+		}
+	}
+
+	if block.Transactions.Len() == 0 {
+		return nil
+	}
+	receipts, err := a.receipts(ctx, number)
+	if err != nil {
+		return fmt.Errorf("error getting receipts of block %d: %w", number, err)
+	}
+	for _, receipt := range receipts {
+		for i := range receipt.Logs {
+			log := &receipt.Logs[i]
+			decoded, err := a.registry.DecodeLog(log)
+			if errors.Is(err, abi.ErrUnknownEvent) {
+				continue
+			}
+			if err != nil {
+				slog.Debug("error decoding log", "block_number", number, "tx_hash", log.TransactionHash.String(), "log_index", log.LogIndex.Uint64(), "error", err)
+				continue
+			}
+			slog.Info("contract event", "block_number", number, "tx_hash", log.TransactionHash.String(), "log_index", log.LogIndex.Uint64(),
+				"contract", log.Address.String(), "event", decoded.Event.Name, argsAttr(decoded.Args))
+		}
+	}
+	return nil
+}
+
+// argsAttr groups the decoded arguments by name, unnamed ones being named after their position.
+func argsAttr(args []abi.Value) slog.Attr {
+	attrs := make([]any, len(args))
+	for i, arg := range args {
+		name := arg.Name
+		if name == "" {
+			name = fmt.Sprintf("arg%d", i)
+		}
+		attrs[i] = slog.String(name, arg.String())
+	}
+	return slog.Group("args", attrs...)
+}
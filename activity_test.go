@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/rafaribe/polygon-client/abi"
+	"github.com/rafaribe/polygon-client/rpc"
+)
+
+func TestActivityLoggerDecodesCallsAndEvents(t *testing.T) {
+	var buf bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(newLogger(&buf, "json", slog.LevelInfo))
+	defer slog.SetDefault(previous)
+
+	token, _ := rpc.HexToAddress("0xc2132d05d31c914a87c6611c10748aeb04b58e8f")
+	recipient := "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48"
+	input, _ := hex.DecodeString("a9059cbb" +
+		"000000000000000000000000a0b86991c6218b36c1d19d4a2e9eb0ce3606eb48" +
+		"00000000000000000000000000000000000000000000000000000000000f4240")
+	transfer, _ := rpc.HexToHash("0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef")
+	from, _ := rpc.HexToHash("0x0000000000000000000000006e1f5c1c8b7c1bb0b2b1f9b9b0d7e6f1c2a3b4c5")
+	to, _ := rpc.HexToHash("0x000000000000000000000000a0b86991c6218b36c1d19d4a2e9eb0ce3606eb48")
+
+	block := &rpc.Block{
+		Number: 42,
+		Transactions: rpc.NewTransactions([]rpc.Transaction{
+			{To: &token, Input: input},
+			// Plain transfers of the native token carry no input
+			{To: &token},
+		}),
+	}
+	a := &activityLogger{
+		registry: abi.DefaultRegistry(),
+		receipts: func(ctx context.Context, number uint64) ([]*rpc.Receipt, error) {
+			return []*rpc.Receipt{{Logs: []rpc.Log{{
+				Address: token,
+				Topics:  []rpc.Hash{transfer, from, to},
+				Data:    input[len(input)-32:],
+			}}}}, nil
+		},
+	}
+	if err := a.logBlock(context.Background(), block); err != nil {
+		t.Fatalf("logBlock returned unexpected error: %v", err)
+	}
+
+	var entries []map[string]interface{}
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		var entry map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("error decoding log entry: %v", err)
+		}
+		entries = append(entries, entry)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected a contract call and a contract event, got %v", entries)
+	}
+	for i, expected := range []struct{ msg, name, key string }{
+		{"contract call", "transfer", "method"},
+		{"contract event", "Transfer", "event"},
+	} {
+		entry := entries[i]
+		args, _ := entry["args"].(map[string]interface{})
+		if entry["msg"] != expected.msg || entry[expected.key] != expected.name || args["to"] != recipient || args["value"] != "1000000" {
+			t.Errorf("expected %s %s to %s of 1000000, got %v", expected.msg, expected.name, recipient, entry)
+		}
+	}
+}
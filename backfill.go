@@ -0,0 +1,264 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/rafaribe/polygon-client/rpc"
+)
+
+// backfillMain runs the backfill subcommand, which writes a range of historical blocks as JSON lines.
+func backfillMain(args []string) {
+	fs := flag.NewFlagSet("polygon-client backfill", flag.ContinueOnError)
+	from := fs.Uint64("from", 0, "first block of the range")
+	to := fs.Uint64("to", 0, "last block of the range, included")
+	workers := fs.Int("workers", 8, "number of blocks fetched in parallel")
+	output := fs.String("output", "-", "file the blocks are appended to, - for stdout")
+	checkpoint := fs.String("checkpoint", "", "file recording the last written block, the backfill resumes from it when restarted")
+
+	cfg, err := loadConfigFlags(fs, args, os.Getenv)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		fatal("error loading configuration", "error", err)
+	}
+	slog.SetDefault(newLogger(os.Stderr, cfg.LogFormat, cfg.LogLevel))
+	if *to < *from {
+		fatal("invalid range: -to is lower than -from", "from", *from, "to", *to)
+	}
+	if *workers <= 0 {
+		fatal("invalid -workers: must be positive", "workers", *workers)
+	}
+
+	client, err := newClient(cfg)
+	if err != nil {
+		fatal("error creating RPC client", "error", err)
+	}
+
+	out := io.Writer(os.Stdout)
+	if *output != "-" {
+		f, err := os.OpenFile(*output, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			fatal("error opening output file", "output", *output, "error", err)
+		}
+		defer f.Close()
+		out = f
+	}
+
+	// Stop on Ctrl+C, the checkpoint lets the next run resume where this one stopped
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	b := &backfiller{
+		fetch: func(ctx context.Context, number uint64) (*rpc.Block, error) {
+			return client.BlockByNumber(ctx, number, cfg.FullTransactions)
+		},
+		workers:          *workers,
+		out:              out,
+		checkpoint:       *checkpoint,
+		progressInterval: backfillProgressInterval,
+	}
+	if err := b.run(ctx, *from, *to); err != nil {
+		fatal("backfill failed", rpc.ErrorAttr(err))
+	}
+}
+
+// backfillProgressInterval is how often progress is reported and the checkpoint written.
+const backfillProgressInterval = time.Second * 10
+
+// backfiller fetches a range of blocks in parallel and writes them in order.
+type backfiller struct {
+	fetch   func(ctx context.Context, number uint64) (*rpc.Block, error)
+	workers int
+	out     io.Writer
+	// checkpoint is the path of the checkpoint file, none is kept when empty
+	checkpoint       string
+	progressInterval time.Duration
+}
+
+type backfillResult struct {
+	number uint64
+	block  *rpc.Block
+	err    error
+}
+
+// run writes the blocks from..to, both included, as JSON lines, resuming after the checkpoint if there is one.
+// Blocks are fetched by parallel workers but written in order, at most twice as many blocks as workers are held in memory.
+func (b *backfiller) run(ctx context.Context, from, to uint64) error {
+	if b.checkpoint != "" {
+		last, ok, err := readCheckpoint(b.checkpoint)
+		if err != nil {
+			return err
+		}
+		if ok && last >= from {
+			slog.Info("resuming from checkpoint", "block_number", last, "checkpoint", b.checkpoint)
+			from = last + 1
+		}
+	}
+	if from > to {
+		slog.Info("blocks already backfilled", "block_number", to)
+		return nil
+	}
+
+	// Workers are stopped and waited for on return, none outlives the backfill
+	var wg sync.WaitGroup
+	ctx, cancel := context.WithCancel(ctx)
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+
+	// window bounds how far ahead of the writer the workers may go
+	window := make(chan struct{}, 2*b.workers)
+	numbers := make(chan uint64)
+	go func() {
+		defer close(numbers)
+		for number := from; number <= to; number++ {
+			select {
+			case window <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case numbers <- number:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	results := make(chan backfillResult)
+	for i := 0; i < b.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for number := range numbers {
+				block, err := b.fetch(ctx, number)
+				select {
+				case results <- backfillResult{number: number, block: block, err: err}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	w := bufio.NewWriter(b.out)
+	enc := json.NewEncoder(w)
+	start := time.Now()
+	total := to - from + 1
+	next := from
+	// save flushes the written blocks and records the last one, so that an interrupted backfill resumes after it
+	save := func() error {
+		if err := w.Flush(); err != nil {
+			return fmt.Errorf("error writing blocks: %v", err)
+		}
+		if b.checkpoint == "" || next == from {
+			return nil
+		}
+		return writeCheckpoint(b.checkpoint, next-1)
+	}
+
+	ticker := time.NewTicker(b.progressInterval)
+	defer ticker.Stop()
+	pending := make(map[uint64]backfillResult)
+	for next <= to {
+		select {
+		case res := <-results:
+			// Failures are handled in order too, every block before a failed one is written and checkpointed
+			pending[res.number] = res
+			for res, ok := pending[next]; ok; res, ok = pending[next] {
+				if res.err != nil {
+					if err := save(); err != nil {
+						slog.Error("error saving checkpoint", "checkpoint", b.checkpoint, "error", err)
+					}
+					return fmt.Errorf("error getting block %d: %w", res.number, res.err)
+				}
+				if err := enc.Encode(res.block); err != nil {
+					return fmt.Errorf("error writing block %d: %v", next, err)
+				}
+				delete(pending, next)
+				<-window
+				next++
+			}
+		case <-ctx.Done():
+			if err := save(); err != nil {
+				slog.Error("error saving checkpoint", "checkpoint", b.checkpoint, "error", err)
+			}
+			return ctx.Err()
+		case <-ticker.C:
+			if err := save(); err != nil {
+				return err
+			}
+			slog.Info("backfill progress", "block_number", next-1, "progress", progress(next-from, total, time.Since(start)))
+		}
+	}
+
+	if err := save(); err != nil {
+		return err
+	}
+	slog.Info("backfill complete", "block_number", to, "progress", progress(total, total, time.Since(start)))
+	return nil
+}
+
+// progress formats how many blocks are done, the throughput and the estimated time left.
+func progress(done, total uint64, elapsed time.Duration) string {
+	rate := float64(done) / elapsed.Seconds()
+	eta := "unknown"
+	if rate > 0 {
+		eta = time.Duration(float64(total-done) / rate * float64(time.Second)).Round(time.Second).String()
+	}
+	return fmt.Sprintf("%d/%d blocks (%.1f%%), %.1f blocks/s, ETA %s", done, total, float64(done)*100/float64(total), rate, eta)
+}
+
+// readCheckpoint returns the last written block recorded in the checkpoint file, ok is false when there is none yet.
+func readCheckpoint(path string) (last uint64, ok bool, err error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("error reading checkpoint: %v", err)
+	}
+	last, err = strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return 0, false, fmt.Errorf("invalid checkpoint %s: %v", path, err)
+	}
+	return last, true, nil
+}
+
+// writeCheckpoint atomically records the last written block, a crash never leaves a truncated checkpoint behind.
+func writeCheckpoint(path string, last uint64) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return fmt.Errorf("error writing checkpoint: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := fmt.Fprintf(tmp, "%d\n", last); err != nil {
+		tmp.Close()
+		return fmt.Errorf("error writing checkpoint: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("error writing checkpoint: %v", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("error writing checkpoint: %v", err)
+	}
+	return nil
+}
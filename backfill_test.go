@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"math/rand"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rafaribe/polygon-client/rpc"
+)
+
+// testFetcher returns blocks after a random delay, so that workers complete out of order, and records the fetched numbers.
+type testFetcher struct {
+	mu      sync.Mutex
+	fetched []uint64
+	failAt  uint64
+}
+
+func (f *testFetcher) fetch(ctx context.Context, number uint64) (*rpc.Block, error) {
+	time.Sleep(time.Duration(rand.Intn(3)) * time.Millisecond)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.fetched = append(f.fetched, number)
+	if number == f.failAt {
+		return nil, rpc.ErrBlockNotFound
+	}
+	return &rpc.Block{Number: rpc.Quantity(number)}, nil
+}
+
+func writtenNumbers(t *testing.T, out *bytes.Buffer) []uint64 {
+	t.Helper()
+	var numbers []uint64
+	scanner := bufio.NewScanner(out)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		var block rpc.Block
+		if err := json.Unmarshal(scanner.Bytes(), &block); err != nil {
+			t.Fatalf("error decoding written block: %v", err)
+		}
+		numbers = append(numbers, block.Number.Uint64())
+	}
+	return numbers
+}
+
+func numberRange(from, to uint64) []uint64 {
+	var numbers []uint64
+	for n := from; n <= to; n++ {
+		numbers = append(numbers, n)
+	}
+	return numbers
+}
+
+func TestBackfillWritesBlocksInOrder(t *testing.T) {
+	var out bytes.Buffer
+	fetcher := &testFetcher{}
+	b := &backfiller{fetch: fetcher.fetch, workers: 4, out: &out, progressInterval: time.Hour}
+
+	if err := b.run(context.Background(), 100, 149); err != nil {
+		t.Fatalf("run returned unexpected error: %v", err)
+	}
+	if numbers := writtenNumbers(t, &out); !reflect.DeepEqual(numbers, numberRange(100, 149)) {
+		t.Errorf("expected blocks 100 to 149 in order, got %v", numbers)
+	}
+}
+
+func TestBackfillResumesFromCheckpoint(t *testing.T) {
+	checkpoint := filepath.Join(t.TempDir(), "checkpoint")
+	var out bytes.Buffer
+
+	// The first run stops at block 20, the blocks before it are written and checkpointed
+	fetcher := &testFetcher{failAt: 20}
+	b := &backfiller{fetch: fetcher.fetch, workers: 4, out: &out, checkpoint: checkpoint, progressInterval: time.Hour}
+	if err := b.run(context.Background(), 1, 30); !errors.Is(err, rpc.ErrBlockNotFound) {
+		t.Fatalf("expected ErrBlockNotFound, got %v", err)
+	}
+	last, ok, err := readCheckpoint(checkpoint)
+	if err != nil || !ok || last != 19 {
+		t.Fatalf("expected checkpoint at block 19, got %d, %t, %v", last, ok, err)
+	}
+
+	// The second run only fetches the remaining blocks
+	fetcher = &testFetcher{}
+	b.fetch = fetcher.fetch
+	if err := b.run(context.Background(), 1, 30); err != nil {
+		t.Fatalf("run returned unexpected error: %v", err)
+	}
+	for _, number := range fetcher.fetched {
+		if number < 20 {
+			t.Errorf("block %d fetched again despite the checkpoint", number)
+		}
+	}
+	if numbers := writtenNumbers(t, &out); !reflect.DeepEqual(numbers, numberRange(1, 30)) {
+		t.Errorf("expected blocks 1 to 30 in order, got %v", numbers)
+	}
+	if last, _, _ := readCheckpoint(checkpoint); last != 30 {
+		t.Errorf("expected checkpoint at block 30, got %d", last)
+	}
+}
+
+func TestBackfillProgress(t *testing.T) {
+	got := progress(250, 1000, 10*time.Second)
+	expected := "250/1000 blocks (25.0%), 25.0 blocks/s, ETA 30s"
+	if got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+}
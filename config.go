@@ -0,0 +1,509 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// envPrefix is prepended to every setting name to build its environment variable.
+const envPrefix = "POLYGON_"
+
+// networkEndpoints holds the public RPC endpoints used when no endpoint is configured explicitly.
+var networkEndpoints = map[string][]string{
+	"mainnet": {"https://polygon-rpc.com"},
+	"amoy":    {"https://rpc-amoy.polygon.technology"},
+}
+
+// config holds the runtime configuration of the poller.
+// Values are resolved with the following precedence, highest first: flags, environment variables, config file, defaults.
+type config struct {
+	Network        string
+	Endpoints      []string
+	WSEndpoint     string
+	Timeout        time.Duration
+	PollInterval   time.Duration
+	ListenAddr     string
+	ReadyStaleness time.Duration
+	// EndpointMaxFailures is the number of consecutive failures after which an endpoint is taken out of rotation
+	EndpointMaxFailures int
+	// EndpointCooldown is how long an unhealthy endpoint stays out of rotation
+	EndpointCooldown time.Duration
+	// RetryMaxAttempts is the total number of attempts of a single request
+	RetryMaxAttempts int
+	// RetryBaseDelay is the backoff ceiling of the first retry, doubled on every attempt up to RetryMaxDelay
+	RetryBaseDelay time.Duration
+	RetryMaxDelay  time.Duration
+	// PollMaxBackoff caps the extra delay added between poll cycles when they keep failing
+	PollMaxBackoff time.Duration
+	// FullTransactions requests blocks with full transaction objects instead of hashes only
+	FullTransactions bool
+	// MaxCatchUp caps how many blocks are fetched in a single cycle when the poller fell behind
+	MaxCatchUp int
+	// CatchUpConcurrency is the number of blocks fetched in parallel while catching up
+	CatchUpConcurrency int
+	// ReorgDepth is the number of recent block headers kept to detect chain reorganisations
+	ReorgDepth int
+	// LogActivity logs the decoded contract calls and events of every processed block
+	LogActivity bool
+	// ABIDir holds JSON ABIs decoding contract activity on top of the built-in ones
+	ABIDir string
+	// OTLPEndpoint is the URL of the OpenTelemetry collector spans are exported to, tracing is disabled when empty
+	OTLPEndpoint string
+	// LogLevel is the minimum level of the logged messages
+	LogLevel slog.Level
+	// LogFormat is either json or text
+	LogFormat string
+	// ShutdownGrace is how long in-flight work may take to wind down once a termination signal is received
+	ShutdownGrace time.Duration
+}
+
+func defaultConfig() config {
+	return config{
+		Network:        "mainnet",
+		Timeout:        time.Second * 5,
+		PollInterval:   time.Second * 5,
+		ListenAddr:     ":3000",
+		ReadyStaleness: time.Second * 30,
+
+		EndpointMaxFailures: 3,
+		EndpointCooldown:    time.Second * 30,
+
+		RetryMaxAttempts: 3,
+		RetryBaseDelay:   time.Millisecond * 250,
+		RetryMaxDelay:    time.Second * 5,
+		PollMaxBackoff:   time.Minute,
+		ShutdownGrace:    time.Second * 10,
+		FullTransactions: true,
+		ReorgDepth:       128,
+
+		MaxCatchUp:         128,
+		CatchUpConcurrency: 4,
+
+		LogLevel:  slog.LevelInfo,
+		LogFormat: "json",
+	}
+}
+
+// setting describes a single configuration value and how it is parsed from its string form.
+// The same name is used as the config file key, the flag name (with dashes) and the environment variable (upper cased, prefixed).
+type setting struct {
+	name  string
+	usage string
+	apply func(c *config, value string) error
+}
+
+var settings = []setting{
+	{
+		name:  "network",
+		usage: "network preset used to pick default endpoints (mainnet, amoy)",
+		apply: func(c *config, value string) error {
+			c.Network = value
+			return nil
+		},
+	},
+	{
+		name:  "endpoints",
+		usage: "comma separated list of RPC endpoint URLs, overrides the network preset",
+		apply: func(c *config, value string) error {
+			c.Endpoints = splitList(value)
+			return nil
+		},
+	},
+	{
+		name:  "ws_endpoint",
+		usage: "WebSocket endpoint URL used to follow new heads instead of polling",
+		apply: func(c *config, value string) error {
+			c.WSEndpoint = value
+			return nil
+		},
+	},
+	{
+		name:  "timeout",
+		usage: "timeout of each RPC request",
+		apply: durationSetter(func(c *config) *time.Duration { return &c.Timeout }),
+	},
+	{
+		name:  "poll_interval",
+		usage: "interval between two polls of the latest block",
+		apply: durationSetter(func(c *config) *time.Duration { return &c.PollInterval }),
+	},
+	{
+		name:  "listen_addr",
+		usage: "address the health check server listens on",
+		apply: func(c *config, value string) error {
+			c.ListenAddr = value
+			return nil
+		},
+	},
+	{
+		name:  "ready_staleness",
+		usage: "maximum age of the last fetched block before /ready fails",
+		apply: durationSetter(func(c *config) *time.Duration { return &c.ReadyStaleness }),
+	},
+	{
+		name:  "endpoint_max_failures",
+		usage: "consecutive failures after which an endpoint is taken out of rotation",
+		apply: intSetter(func(c *config) *int { return &c.EndpointMaxFailures }),
+	},
+	{
+		name:  "endpoint_cooldown",
+		usage: "how long an unhealthy endpoint stays out of rotation",
+		apply: durationSetter(func(c *config) *time.Duration { return &c.EndpointCooldown }),
+	},
+	{
+		name:  "retry_max_attempts",
+		usage: "total number of attempts of a single request",
+		apply: intSetter(func(c *config) *int { return &c.RetryMaxAttempts }),
+	},
+	{
+		name:  "retry_base_delay",
+		usage: "backoff ceiling of the first retry, doubled on every attempt",
+		apply: durationSetter(func(c *config) *time.Duration { return &c.RetryBaseDelay }),
+	},
+	{
+		name:  "retry_max_delay",
+		usage: "maximum backoff between two attempts of a request",
+		apply: durationSetter(func(c *config) *time.Duration { return &c.RetryMaxDelay }),
+	},
+	{
+		name:  "poll_max_backoff",
+		usage: "maximum extra delay between poll cycles when they keep failing",
+		apply: durationSetter(func(c *config) *time.Duration { return &c.PollMaxBackoff }),
+	},
+	{
+		name:  "full_transactions",
+		usage: "request blocks with full transaction objects instead of hashes only",
+		apply: boolSetter(func(c *config) *bool { return &c.FullTransactions }),
+	},
+	{
+		name:  "max_catch_up",
+		usage: "maximum number of blocks fetched in a single cycle when the poller fell behind",
+		apply: intSetter(func(c *config) *int { return &c.MaxCatchUp }),
+	},
+	{
+		name:  "catch_up_concurrency",
+		usage: "number of blocks fetched in parallel while catching up",
+		apply: intSetter(func(c *config) *int { return &c.CatchUpConcurrency }),
+	},
+	{
+		name:  "reorg_depth",
+		usage: "number of recent block headers kept to detect chain reorganisations",
+		apply: intSetter(func(c *config) *int { return &c.ReorgDepth }),
+	},
+	{
+		name:  "log_activity",
+		usage: "log the contract calls and events of every processed block, decoded with the built-in and abi_dir ABIs",
+		apply: boolSetter(func(c *config) *bool { return &c.LogActivity }),
+	},
+	{
+		name:  "abi_dir",
+		usage: "directory of JSON ABI files decoding contract activity, files named after a contract address only apply to it",
+		apply: func(c *config, value string) error {
+			c.ABIDir = value
+			return nil
+		},
+	},
+	{
+		name:  "otlp_endpoint",
+		usage: "OTLP/HTTP collector URL traces are exported to, e.g. http://localhost:4318, tracing is disabled when empty",
+		apply: func(c *config, value string) error {
+			c.OTLPEndpoint = value
+			return nil
+		},
+	},
+	{
+		name:  "log_level",
+		usage: "minimum level of the logged messages (debug, info, warn, error)",
+		apply: func(c *config, value string) error {
+			return c.LogLevel.UnmarshalText([]byte(value))
+		},
+	},
+	{
+		name:  "log_format",
+		usage: "format of the logs written to stderr (json, text)",
+		apply: func(c *config, value string) error {
+			c.LogFormat = strings.ToLower(value)
+			return nil
+		},
+	},
+	{
+		name:  "shutdown_grace",
+		usage: "how long in-flight work may take to wind down on SIGINT or SIGTERM",
+		apply: durationSetter(func(c *config) *time.Duration { return &c.ShutdownGrace }),
+	},
+}
+
+func durationSetter(field func(c *config) *time.Duration) func(c *config, value string) error {
+	return func(c *config, value string) error {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		*field(c) = d
+		return nil
+	}
+}
+
+func intSetter(field func(c *config) *int) func(c *config, value string) error {
+	return func(c *config, value string) error {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		*field(c) = n
+		return nil
+	}
+}
+
+func boolSetter(field func(c *config) *bool) func(c *config, value string) error {
+	return func(c *config, value string) error {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		*field(c) = b
+		return nil
+	}
+}
+
+func (s setting) flagName() string {
+	return strings.ReplaceAll(s.name, "_", "-")
+}
+
+func (s setting) envName() string {
+	return envPrefix + strings.ToUpper(s.name)
+}
+
+// fieldError reports an invalid configuration value, naming the field and where it was read from.
+type fieldError struct {
+	Field  string
+	Source string
+	Err    error
+}
+
+func (e *fieldError) Error() string {
+	if e.Source == "" {
+		return fmt.Sprintf("invalid %s: %v", e.Field, e.Err)
+	}
+	return fmt.Sprintf("invalid %s (from %s): %v", e.Field, e.Source, e.Err)
+}
+
+func (e *fieldError) Unwrap() error { return e.Err }
+
+// loadConfig resolves the configuration from the command line arguments, the environment and an optional config file.
+func loadConfig(args []string, getenv func(string) string) (*config, error) {
+	return loadConfigFlags(flag.NewFlagSet("polygon-client", flag.ContinueOnError), args, getenv)
+}
+
+// loadConfigFlags is loadConfig parsing args with fs, on which callers may define flags of their own.
+func loadConfigFlags(fs *flag.FlagSet, args []string, getenv func(string) string) (*config, error) {
+	configPath := fs.String("config", "", "path to a YAML or TOML config file")
+	flagValues := make(map[string]*string, len(settings))
+	for _, s := range settings {
+		flagValues[s.name] = fs.String(s.flagName(), "", fmt.Sprintf("%s (env %s)", s.usage, s.envName()))
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	cfg := defaultConfig()
+
+	path := *configPath
+	if path == "" {
+		path = getenv(envPrefix + "CONFIG")
+	}
+	if path != "" {
+		values, err := readConfigFile(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range settings {
+			if value, ok := values[s.name]; ok {
+				if err := s.apply(&cfg, value); err != nil {
+					return nil, &fieldError{Field: s.name, Source: path, Err: err}
+				}
+			}
+		}
+	}
+
+	for _, s := range settings {
+		if value := getenv(s.envName()); value != "" {
+			if err := s.apply(&cfg, value); err != nil {
+				return nil, &fieldError{Field: s.name, Source: s.envName(), Err: err}
+			}
+		}
+	}
+
+	explicit := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { explicit[f.Name] = true })
+	for _, s := range settings {
+		if explicit[s.flagName()] {
+			if err := s.apply(&cfg, *flagValues[s.name]); err != nil {
+				return nil, &fieldError{Field: s.name, Source: "-" + s.flagName(), Err: err}
+			}
+		}
+	}
+
+	if len(cfg.Endpoints) == 0 {
+		cfg.Endpoints = networkEndpoints[cfg.Network]
+	}
+
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// validate checks the resolved configuration, the returned error names the offending field.
+func (c *config) validate() error {
+	if _, ok := networkEndpoints[c.Network]; !ok {
+		return &fieldError{Field: "network", Err: fmt.Errorf("unknown network %q", c.Network)}
+	}
+	if len(c.Endpoints) == 0 {
+		return &fieldError{Field: "endpoints", Err: fmt.Errorf("at least one endpoint is required")}
+	}
+	for _, endpoint := range c.Endpoints {
+		u, err := url.Parse(endpoint)
+		if err != nil {
+			return &fieldError{Field: "endpoints", Err: err}
+		}
+		if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return &fieldError{Field: "endpoints", Err: fmt.Errorf("%q is not an http(s) URL", endpoint)}
+		}
+	}
+	if c.WSEndpoint != "" {
+		u, err := url.Parse(c.WSEndpoint)
+		if err != nil {
+			return &fieldError{Field: "ws_endpoint", Err: err}
+		}
+		if (u.Scheme != "ws" && u.Scheme != "wss") || u.Host == "" {
+			return &fieldError{Field: "ws_endpoint", Err: fmt.Errorf("%q is not a ws(s) URL", c.WSEndpoint)}
+		}
+	}
+	if c.Timeout <= 0 {
+		return &fieldError{Field: "timeout", Err: fmt.Errorf("must be positive, got %s", c.Timeout)}
+	}
+	if c.PollInterval <= 0 {
+		return &fieldError{Field: "poll_interval", Err: fmt.Errorf("must be positive, got %s", c.PollInterval)}
+	}
+	if c.ListenAddr == "" {
+		return &fieldError{Field: "listen_addr", Err: fmt.Errorf("must not be empty")}
+	}
+	if c.ReadyStaleness <= 0 {
+		return &fieldError{Field: "ready_staleness", Err: fmt.Errorf("must be positive, got %s", c.ReadyStaleness)}
+	}
+	if c.EndpointMaxFailures <= 0 {
+		return &fieldError{Field: "endpoint_max_failures", Err: fmt.Errorf("must be positive, got %d", c.EndpointMaxFailures)}
+	}
+	if c.EndpointCooldown < 0 {
+		return &fieldError{Field: "endpoint_cooldown", Err: fmt.Errorf("must not be negative, got %s", c.EndpointCooldown)}
+	}
+	if c.RetryMaxAttempts <= 0 {
+		return &fieldError{Field: "retry_max_attempts", Err: fmt.Errorf("must be positive, got %d", c.RetryMaxAttempts)}
+	}
+	if c.RetryBaseDelay < 0 {
+		return &fieldError{Field: "retry_base_delay", Err: fmt.Errorf("must not be negative, got %s", c.RetryBaseDelay)}
+	}
+	if c.RetryMaxDelay < c.RetryBaseDelay {
+		return &fieldError{Field: "retry_max_delay", Err: fmt.Errorf("must not be lower than retry_base_delay, got %s", c.RetryMaxDelay)}
+	}
+	if c.PollMaxBackoff < 0 {
+		return &fieldError{Field: "poll_max_backoff", Err: fmt.Errorf("must not be negative, got %s", c.PollMaxBackoff)}
+	}
+	if c.MaxCatchUp <= 0 {
+		return &fieldError{Field: "max_catch_up", Err: fmt.Errorf("must be positive, got %d", c.MaxCatchUp)}
+	}
+	if c.CatchUpConcurrency <= 0 {
+		return &fieldError{Field: "catch_up_concurrency", Err: fmt.Errorf("must be positive, got %d", c.CatchUpConcurrency)}
+	}
+	if c.ReorgDepth <= 0 {
+		return &fieldError{Field: "reorg_depth", Err: fmt.Errorf("must be positive, got %d", c.ReorgDepth)}
+	}
+	if c.OTLPEndpoint != "" {
+		u, err := url.Parse(c.OTLPEndpoint)
+		if err != nil {
+			return &fieldError{Field: "otlp_endpoint", Err: err}
+		}
+		if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return &fieldError{Field: "otlp_endpoint", Err: fmt.Errorf("%q is not an http(s) URL", c.OTLPEndpoint)}
+		}
+	}
+	if c.LogFormat != "json" && c.LogFormat != "text" {
+		return &fieldError{Field: "log_format", Err: fmt.Errorf("must be json or text, got %q", c.LogFormat)}
+	}
+	if c.ShutdownGrace <= 0 {
+		return &fieldError{Field: "shutdown_grace", Err: fmt.Errorf("must be positive, got %s", c.ShutdownGrace)}
+	}
+	return nil
+}
+
+// readConfigFile decodes a YAML or TOML file, picked by extension, into the string form of each setting.
+func readConfigFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading config file: %v", err)
+	}
+
+	raw := make(map[string]interface{})
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &raw)
+	case ".toml":
+		err = toml.Unmarshal(data, &raw)
+	default:
+		return nil, fmt.Errorf("unsupported config file extension %q, expected .yaml, .yml or .toml", ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error parsing config file %s: %v", path, err)
+	}
+
+	known := make(map[string]bool, len(settings))
+	for _, s := range settings {
+		known[s.name] = true
+	}
+
+	values := make(map[string]string, len(raw))
+	keys := make([]string, 0, len(raw))
+	for key := range raw {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if !known[key] {
+			return nil, &fieldError{Field: key, Source: path, Err: fmt.Errorf("unknown setting")}
+		}
+		switch v := raw[key].(type) {
+		case []interface{}:
+			items := make([]string, len(v))
+			for i, item := range v {
+				items[i] = fmt.Sprint(item)
+			}
+			values[key] = strings.Join(items, ",")
+		default:
+			values[key] = fmt.Sprint(v)
+		}
+	}
+	return values, nil
+}
+
+// splitList splits a comma separated value, dropping empty items.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func envFromMap(env map[string]string) func(string) string {
+	return func(key string) string { return env[key] }
+}
+
+func TestLoadConfigDefaults(t *testing.T) {
+	cfg, err := loadConfig(nil, envFromMap(nil))
+	if err != nil {
+		t.Fatalf("loadConfig returned unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(cfg.Endpoints, []string{"https://polygon-rpc.com"}) {
+		t.Errorf("expected mainnet endpoint, got %v", cfg.Endpoints)
+	}
+	if cfg.Timeout != 5*time.Second || cfg.PollInterval != 5*time.Second {
+		t.Errorf("expected 5s timeout and poll interval, got %s and %s", cfg.Timeout, cfg.PollInterval)
+	}
+	if cfg.ListenAddr != ":3000" {
+		t.Errorf("expected listen address :3000, got %s", cfg.ListenAddr)
+	}
+}
+
+func TestLoadConfigPrecedence(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	file := `
+network: amoy
+poll_interval: 10s
+timeout: 2s
+listen_addr: ":4000"
+`
+	if err := os.WriteFile(path, []byte(file), 0o600); err != nil {
+		t.Fatalf("error writing config file: %v", err)
+	}
+
+	env := map[string]string{
+		"POLYGON_CONFIG":        path,
+		"POLYGON_POLL_INTERVAL": "7s",
+		"POLYGON_TIMEOUT":       "3s",
+	}
+	args := []string{"-timeout", "4s"}
+
+	cfg, err := loadConfig(args, envFromMap(env))
+	if err != nil {
+		t.Fatalf("loadConfig returned unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(cfg.Endpoints, []string{"https://rpc-amoy.polygon.technology"}) {
+		t.Errorf("expected amoy endpoint from file, got %v", cfg.Endpoints)
+	}
+	if cfg.ListenAddr != ":4000" {
+		t.Errorf("expected listen address from file, got %s", cfg.ListenAddr)
+	}
+	if cfg.PollInterval != 7*time.Second {
+		t.Errorf("expected poll interval from env, got %s", cfg.PollInterval)
+	}
+	if cfg.Timeout != 4*time.Second {
+		t.Errorf("expected timeout from flag, got %s", cfg.Timeout)
+	}
+}
+
+func TestLoadConfigTOML(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.toml")
+	file := `
+endpoints = ["https://node-a.example.com", "https://node-b.example.com"]
+ready_staleness = "1m"
+`
+	if err := os.WriteFile(path, []byte(file), 0o600); err != nil {
+		t.Fatalf("error writing config file: %v", err)
+	}
+
+	cfg, err := loadConfig([]string{"-config", path}, envFromMap(nil))
+	if err != nil {
+		t.Fatalf("loadConfig returned unexpected error: %v", err)
+	}
+
+	expected := []string{"https://node-a.example.com", "https://node-b.example.com"}
+	if !reflect.DeepEqual(cfg.Endpoints, expected) {
+		t.Errorf("expected endpoints %v, got %v", expected, cfg.Endpoints)
+	}
+	if cfg.ReadyStaleness != time.Minute {
+		t.Errorf("expected ready staleness 1m, got %s", cfg.ReadyStaleness)
+	}
+}
+
+func TestLoadConfigErrorsNameField(t *testing.T) {
+	tests := []struct {
+		name  string
+		args  []string
+		env   map[string]string
+		field string
+	}{
+		{name: "bad duration flag", args: []string{"-poll-interval", "fast"}, field: "poll_interval"},
+		{name: "bad duration env", env: map[string]string{"POLYGON_TIMEOUT": "5"}, field: "timeout"},
+		{name: "negative duration", args: []string{"-ready-staleness", "-1s"}, field: "ready_staleness"},
+		{name: "unknown network", env: map[string]string{"POLYGON_NETWORK": "mumbai"}, field: "network"},
+		{name: "bad endpoint", args: []string{"-endpoints", "polygon-rpc.com"}, field: "endpoints"},
+		{name: "bad OTLP endpoint", env: map[string]string{"POLYGON_OTLP_ENDPOINT": "localhost:4318"}, field: "otlp_endpoint"},
+		{name: "bad log level", env: map[string]string{"POLYGON_LOG_LEVEL": "verbose"}, field: "log_level"},
+		{name: "bad log format", args: []string{"-log-format", "logfmt"}, field: "log_format"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := loadConfig(tt.args, envFromMap(tt.env))
+			var fieldErr *fieldError
+			if !errors.As(err, &fieldErr) {
+				t.Fatalf("expected a field error, got %v", err)
+			}
+			if fieldErr.Field != tt.field {
+				t.Errorf("expected error on field %s, got %s (%v)", tt.field, fieldErr.Field, err)
+			}
+		})
+	}
+}
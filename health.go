@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// healthServer serves the liveness and readiness probes used by the load balancer.
+type healthServer struct {
+	// staleness is how old the last fetched block may be before the service reports not ready
+	staleness time.Duration
+	// lastBlock holds the wall clock time, in unix nanoseconds, of the last successful block fetch
+	lastBlock atomic.Int64
+}
+
+func newHealthServer(staleness time.Duration) *healthServer {
+	return &healthServer{staleness: staleness}
+}
+
+// markBlock records that the polling loop has successfully fetched a block at t.
+func (h *healthServer) markBlock(t time.Time) {
+	h.lastBlock.Store(t.UnixNano())
+}
+
+// handler returns the HTTP handler exposing /health and /ready.
+func (h *healthServer) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/health", h.handleHealth)
+	mux.HandleFunc("/ready", h.handleReady)
+	return mux
+}
+
+// handleHealth reports liveness, it succeeds as long as the process is able to serve requests.
+func (h *healthServer) handleHealth(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintln(w, "ok")
+}
+
+// handleReady only succeeds once the polling loop has fetched a block within the staleness window.
+func (h *healthServer) handleReady(w http.ResponseWriter, r *http.Request) {
+	last := h.lastBlock.Load()
+	if last == 0 {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprintln(w, "no block fetched yet")
+		return
+	}
+
+	age := time.Since(time.Unix(0, last))
+	if age > h.staleness {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprintf(w, "last block fetched %s ago\n", age.Round(time.Second))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintln(w, "ok")
+}
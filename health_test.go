@@ -0,0 +1,58 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHealthAlwaysOK(t *testing.T) {
+	health := newHealthServer(time.Second)
+	server := httptest.NewServer(health.handler())
+	defer server.Close()
+
+	resp, err := server.Client().Get(server.URL + "/health")
+	if err != nil {
+		t.Fatalf("error calling /health: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected status %d, got %d", http.StatusOK, resp.StatusCode)
+	}
+}
+
+func TestReadyStaleness(t *testing.T) {
+	health := newHealthServer(time.Minute)
+	server := httptest.NewServer(health.handler())
+	defer server.Close()
+
+	tests := []struct {
+		name      string
+		lastBlock time.Time
+		expected  int
+	}{
+		{name: "no block fetched", expected: http.StatusServiceUnavailable},
+		{name: "stale block", lastBlock: time.Now().Add(-2 * time.Minute), expected: http.StatusServiceUnavailable},
+		{name: "fresh block", lastBlock: time.Now(), expected: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !tt.lastBlock.IsZero() {
+				health.markBlock(tt.lastBlock)
+			}
+
+			resp, err := server.Client().Get(server.URL + "/ready")
+			if err != nil {
+				t.Fatalf("error calling /ready: %v", err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.expected {
+				t.Errorf("expected status %d, got %d", tt.expected, resp.StatusCode)
+			}
+		})
+	}
+}
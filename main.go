@@ -1,67 +1,144 @@
 package main
 
 import (
-	"encoding/json"
-	"log"
+	"context"
+	"errors"
+	"flag"
+	"log/slog"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
+
+	"github.com/rafaribe/polygon-client/abi"
+	"github.com/rafaribe/polygon-client/rpc"
 )
 
 func main() {
-	// Create an HTTP client to make requests to the Polygon RPC endpoint
-	client := http.Client{
-		Timeout: time.Second * 5,
+	if len(os.Args) > 1 && os.Args[1] == "backfill" {
+		backfillMain(os.Args[2:])
+		return
 	}
-	// This could be an env var or an array of endpoints if we wish to expand the application further
-	polygonRpcEndpoint := "https://polygon-rpc.com"
-	// Start an infinite loop to periodically make requests to the RPC endpoint
-	for {
-		// Get the latest block number
-		blockNumberReq := map[string]interface{}{
-			"jsonrpc": "2.0",
-			"method":  "eth_blockNumber",
-			"id":      1,
-		}
 
-		blockNumberRespBytes, err := makeRPCRequest(&client, polygonRpcEndpoint, blockNumberReq)
+	cfg, err := loadConfig(os.Args[1:], os.Getenv)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		fatal("error loading configuration", "error", err)
+	}
+	slog.SetDefault(newLogger(os.Stderr, cfg.LogFormat, cfg.LogLevel))
+
+	// Trace every poll cycle along with its RPC calls, the client picks up the global tracer provider
+	shutdownTracing := func(context.Context) error { return nil }
+	if cfg.OTLPEndpoint != "" {
+		shutdownTracing, err = setupTracing(context.Background(), cfg.OTLPEndpoint)
 		if err != nil {
-			log.Printf("error getting block number: %v", err)
-			continue
+			fatal("error setting up tracing", "error", err)
 		}
+	}
 
-		var blockNumberResp BlockNumberResponse
-		if err := json.Unmarshal(blockNumberRespBytes, &blockNumberResp); err != nil {
-			log.Printf("error unmarshalling block number response: %v", err)
-			continue
-		}
+	// Record what the RPC client and the poller do, for scraping by Prometheus
+	metrics := newMetrics()
+	client, err := newClient(cfg, rpc.WithObserver(metrics))
+	if err != nil {
+		fatal("error creating RPC client", "error", err)
+	}
+	defer client.Close()
+	metrics.registerEndpoints(client.Endpoints)
 
-		// Get the latest block
-		blockReq := map[string]interface{}{
-			"jsonrpc": "2.0",
-			"method":  "eth_getBlockByNumber",
-			"params": []interface{}{
-				blockNumberResp.Result,
-				true,
-			},
-			"id": 2,
+	// Serve the health checks the load balancer target group expects, along with the metrics
+	health := newHealthServer(cfg.ReadyStaleness)
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.handler())
+	mux.Handle("/", health.handler())
+	server := &http.Server{
+		Addr:              cfg.ListenAddr,
+		Handler:           mux,
+		ReadHeaderTimeout: time.Second * 5,
+	}
+	go func() {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			fatal("error serving health checks", "listen_addr", cfg.ListenAddr, "error", err)
 		}
+	}()
 
-		blockRespBytes, err := makeRPCRequest(&client, polygonRpcEndpoint, blockReq)
-		if err != nil {
-			log.Printf("error getting block: %v", err)
-			continue
-		}
+	// Cancel in-flight requests on Ctrl+C or when ECS stops the task
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Recent headers are kept to detect reorganisations, walking back to the common ancestor by parent hash.
+	// The blocks fetched on the way are processed like any other, so they are fetched the same way.
+	chain := newChainTracker(cfg.ReorgDepth, func(ctx context.Context, hash rpc.Hash) (*rpc.Block, error) {
+		return client.BlockByHash(ctx, hash, cfg.FullTransactions)
+	})
 
-		var blockResp BlockResponse
-		if err := json.Unmarshal(blockRespBytes, &blockResp); err != nil {
-			log.Printf("error unmarshalling block response: %v", err)
-			continue
+	p := &poller{
+		client:      client,
+		health:      health,
+		subscribe:   cfg.WSEndpoint != "",
+		chain:       chain,
+		onBlock:     metrics.observeBlock,
+		onReorg:     metrics.observeReorg,
+		fullTx:      cfg.FullTransactions,
+		maxCatchUp:  cfg.MaxCatchUp,
+		concurrency: cfg.CatchUpConcurrency,
+		interval:    cfg.PollInterval,
+		backoff: rpc.RetryPolicy{
+			BaseDelay: cfg.PollInterval,
+			MaxDelay:  cfg.PollMaxBackoff,
+		},
+	}
+	if cfg.LogActivity {
+		registry := abi.DefaultRegistry()
+		if cfg.ABIDir != "" {
+			if err := registry.LoadDir(cfg.ABIDir); err != nil {
+				fatal("error loading ABIs", "abi_dir", cfg.ABIDir, "error", err)
+			}
 		}
+		p.activity = &activityLogger{registry: registry, receipts: client.BlockReceipts}
+	}
+	done := make(chan struct{})
+	go func() {
+		p.run(ctx)
+		close(done)
+	}()
+
+	<-ctx.Done()
+	// A second signal kills the process right away
+	stop()
+	slog.Info("shutting down", "grace_ms", cfg.ShutdownGrace.Milliseconds())
 
-		log.Printf("Latest block number: %s", blockNumberResp.Result)
-		log.Printf("Latest block hash: %s", blockResp.Result.Hash)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownGrace)
+	defer cancel()
+	select {
+	case <-done:
+	case <-shutdownCtx.Done():
+		fatal("poller did not stop in time", "grace_ms", cfg.ShutdownGrace.Milliseconds())
+	}
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		fatal("error shutting down health check server", "error", err)
+	}
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		slog.Error("error flushing traces", "error", err)
+	}
+	slog.Info("shutdown complete")
+}
 
-		// Wait for 5 seconds before making the next request
-		time.Sleep(time.Second * 5)
+// newClient creates the RPC client, requests fail over between the configured endpoints.
+func newClient(cfg *config, extra ...rpc.Option) (*rpc.Client, error) {
+	opts := []rpc.Option{
+		rpc.WithHTTPClient(&http.Client{Timeout: cfg.Timeout}),
+		rpc.WithEndpointHealth(cfg.EndpointMaxFailures, cfg.EndpointCooldown),
+		rpc.WithRetryPolicy(rpc.RetryPolicy{
+			MaxAttempts: cfg.RetryMaxAttempts,
+			BaseDelay:   cfg.RetryBaseDelay,
+			MaxDelay:    cfg.RetryMaxDelay,
+		}),
+	}
+	if cfg.WSEndpoint != "" {
+		opts = append(opts, rpc.WithWebSocket(cfg.WSEndpoint), rpc.WithPollInterval(cfg.PollInterval))
 	}
+	return rpc.NewClient(cfg.Endpoints, append(opts, extra...)...)
 }
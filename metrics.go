@@ -0,0 +1,143 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/rafaribe/polygon-client/rpc"
+)
+
+const metricsNamespace = "polygon_client"
+
+// metrics records what the poller and the RPC client do, for scraping by Prometheus.
+type metrics struct {
+	registry *prometheus.Registry
+
+	latestBlock     prometheus.Gauge
+	blockLag        prometheus.Gauge
+	requestDuration *prometheus.HistogramVec
+	requestErrors   *prometheus.CounterVec
+	reorgs          prometheus.Counter
+	reorgDepth      prometheus.Histogram
+}
+
+// newMetrics creates the metrics in a registry of their own, along with the Go runtime and process metrics.
+func newMetrics() *metrics {
+	m := &metrics{
+		registry: prometheus.NewRegistry(),
+		latestBlock: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "latest_block",
+			Help:      "Number of the latest processed block.",
+		}),
+		blockLag: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "block_lag_seconds",
+			Help:      "Difference between the wall clock and the timestamp of the latest processed block.",
+		}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "rpc_request_duration_seconds",
+			Help:      "Duration of the JSON-RPC requests sent to each endpoint, by method.",
+			Buckets:   prometheus.ExponentialBuckets(0.01, 2, 12),
+		}, []string{"method", "endpoint"}),
+		requestErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "rpc_errors_total",
+			Help:      "Failed JSON-RPC requests by method, endpoint and error type (transport, http_status, jsonrpc, protocol, decode), code holds the HTTP status or JSON-RPC error code.",
+		}, []string{"method", "endpoint", "type", "code"}),
+		reorgs: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "reorgs_total",
+			Help:      "Number of detected chain reorganisations.",
+		}),
+		reorgDepth: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "reorg_depth",
+			Help:      "Number of blocks orphaned by each detected chain reorganisation.",
+			Buckets:   []float64{1, 2, 3, 5, 8, 13, 21, 34, 64, 128},
+		}),
+	}
+	m.registry.MustRegister(
+		m.latestBlock,
+		m.blockLag,
+		m.requestDuration,
+		m.requestErrors,
+		m.reorgs,
+		m.reorgDepth,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return m
+}
+
+// registerEndpoints reports the health of the RPC endpoints, as returned by endpoints at scrape time.
+func (m *metrics) registerEndpoints(endpoints func() []rpc.EndpointStatus) {
+	m.registry.MustRegister(&endpointCollector{endpoints: endpoints})
+}
+
+// handler returns the HTTP handler exposing the metrics.
+func (m *metrics) handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// observeBlock records a processed block.
+func (m *metrics) observeBlock(block *rpc.Block) {
+	m.latestBlock.Set(float64(block.Number.Uint64()))
+	m.blockLag.Set(time.Since(block.Timestamp).Seconds())
+}
+
+// observeReorg records a detected chain reorganisation.
+func (m *metrics) observeReorg(e reorgEvent) {
+	m.reorgs.Inc()
+	m.reorgDepth.Observe(float64(e.Depth))
+}
+
+// ObserveRequest implements rpc.Observer.
+func (m *metrics) ObserveRequest(method, endpoint string, latency time.Duration, err error) {
+	m.requestDuration.WithLabelValues(method, endpoint).Observe(latency.Seconds())
+	if err != nil {
+		kind, code := rpc.ClassifyError(err)
+		m.requestErrors.WithLabelValues(method, endpoint, kind, code).Inc()
+	}
+}
+
+// endpointCollector reports the health of the RPC endpoints as seen by the client when scraped.
+type endpointCollector struct {
+	endpoints func() []rpc.EndpointStatus
+}
+
+var (
+	endpointHealthyDesc = prometheus.NewDesc(metricsNamespace+"_endpoint_healthy",
+		"Whether the endpoint is in rotation (1) or cooling down after consecutive failures (0).", []string{"endpoint"}, nil)
+	endpointFailuresDesc = prometheus.NewDesc(metricsNamespace+"_endpoint_consecutive_failures",
+		"Number of consecutive failed requests to the endpoint.", []string{"endpoint"}, nil)
+	endpointLatencyDesc = prometheus.NewDesc(metricsNamespace+"_endpoint_latency_seconds",
+		"Moving average of the successful request durations of the endpoint.", []string{"endpoint"}, nil)
+	endpointBlockDesc = prometheus.NewDesc(metricsNamespace+"_endpoint_block",
+		"Highest block number reported by the endpoint.", []string{"endpoint"}, nil)
+)
+
+func (c *endpointCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- endpointHealthyDesc
+	ch <- endpointFailuresDesc
+	ch <- endpointLatencyDesc
+	ch <- endpointBlockDesc
+}
+
+func (c *endpointCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, e := range c.endpoints() {
+		healthy := 0.0
+		if e.Healthy {
+			healthy = 1
+		}
+		ch <- prometheus.MustNewConstMetric(endpointHealthyDesc, prometheus.GaugeValue, healthy, e.Name)
+		ch <- prometheus.MustNewConstMetric(endpointFailuresDesc, prometheus.GaugeValue, float64(e.ConsecutiveFailures), e.Name)
+		ch <- prometheus.MustNewConstMetric(endpointLatencyDesc, prometheus.GaugeValue, e.Latency.Seconds(), e.Name)
+		ch <- prometheus.MustNewConstMetric(endpointBlockDesc, prometheus.GaugeValue, float64(e.LastBlock), e.Name)
+	}
+}
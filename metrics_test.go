@@ -0,0 +1,49 @@
+package main
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rafaribe/polygon-client/rpc"
+)
+
+func TestMetricsHandler(t *testing.T) {
+	m := newMetrics()
+	m.registerEndpoints(func() []rpc.EndpointStatus {
+		return []rpc.EndpointStatus{{URL: "https://node-a.example.com/v2/0123456789abcdef", Name: "https://node-a.example.com", Healthy: true, LastBlock: 42}}
+	})
+	m.observeBlock(&rpc.Block{Number: 42, Timestamp: time.Now()})
+	m.observeReorg(reorgEvent{Depth: 2})
+	m.ObserveRequest("eth_blockNumber", "https://node-a.example.com", time.Millisecond, &rpc.HTTPStatusError{StatusCode: 503})
+
+	server := httptest.NewServer(m.handler())
+	defer server.Close()
+	resp, err := server.Client().Get(server.URL)
+	if err != nil {
+		t.Fatalf("error calling /metrics: %v", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("error reading metrics: %v", err)
+	}
+
+	for _, expected := range []string{
+		`polygon_client_latest_block 42`,
+		`polygon_client_reorgs_total 1`,
+		`polygon_client_rpc_errors_total{code="503",endpoint="https://node-a.example.com",method="eth_blockNumber",type="http_status"} 1`,
+		`polygon_client_rpc_request_duration_seconds_count{endpoint="https://node-a.example.com",method="eth_blockNumber"} 1`,
+		`polygon_client_endpoint_healthy{endpoint="https://node-a.example.com"} 1`,
+		`polygon_client_endpoint_block{endpoint="https://node-a.example.com"} 42`,
+	} {
+		if !strings.Contains(string(body), expected) {
+			t.Errorf("expected metrics to contain %s", expected)
+		}
+	}
+	if strings.Contains(string(body), "0123456789abcdef") {
+		t.Errorf("expected the API key of the endpoint URL to be left out of the metrics")
+	}
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/rafaribe/polygon-client/rpc"
+)
+
+// poller fetches the latest block through the RPC client, following new heads when subscriptions are available.
+type poller struct {
+	client *rpc.Client
+	health *healthServer
+	// subscribe follows new heads through a subscription instead of polling on an interval
+	subscribe bool
+	// chain follows the canonical chain through the fetched blocks to detect reorganisations
+	chain *chainTracker
+	// onBlock and onReorg, when set, are notified of every processed block and detected reorganisation
+	onBlock func(*rpc.Block)
+	onReorg func(reorgEvent)
+	// activity, when set, logs the decoded contract calls and events of every processed block
+	activity *activityLogger
+	// fullTx requests blocks with full transaction objects instead of hashes only
+	fullTx bool
+	// maxCatchUp caps how many blocks are fetched in a single cycle, older skipped blocks are given up on
+	maxCatchUp int
+	// concurrency is the number of blocks fetched in parallel while catching up
+	concurrency int
+	// last is the number of the last processed block, zero before the first one
+	last uint64
+	// interval is the delay between two successful poll cycles
+	interval time.Duration
+	// backoff delays the next poll cycle, on top of the interval, when a whole cycle fails
+	backoff rpc.RetryPolicy
+}
+
+// run follows the chain until ctx is done.
+// When subscribing is enabled new heads drive the fetching, otherwise or if the subscription fails the poller polls on its interval.
+func (p *poller) run(ctx context.Context) {
+	if p.subscribe {
+		err := p.follow(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		slog.Warn("new heads subscription failed, falling back to polling", rpc.ErrorAttr(err))
+	}
+	p.poll(ctx)
+}
+
+// follow processes the blocks up to every new head until ctx is done or the subscription fails.
+func (p *poller) follow(ctx context.Context) error {
+	heads := make(chan *rpc.Block, 16)
+	sub, err := p.client.SubscribeNewHeads(ctx, heads)
+	if err != nil {
+		return err
+	}
+	defer sub.Unsubscribe()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-sub.Err():
+			return err
+		case head := <-heads:
+			if err := p.followHead(ctx, head.Number.Uint64()); err != nil && ctx.Err() == nil {
+				slog.Error("error following head", "block_number", head.Number.Uint64(), rpc.ErrorAttr(err))
+			}
+		}
+	}
+}
+
+// poll polls until ctx is done, backing off when consecutive poll cycles fail.
+func (p *poller) poll(ctx context.Context) {
+	failures := 0
+	for {
+		// Wait for the poll interval before making the next request
+		delay := p.interval
+		if err := p.pollOnce(ctx); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			failures++
+			delay += p.backoff.Backoff(failures)
+			slog.Error("poll cycle failed", "consecutive_failures", failures, "retry_in_ms", delay.Milliseconds(), rpc.ErrorAttr(err))
+		} else {
+			failures = 0
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// followHead processes the blocks up to a new head, in a span of its own.
+func (p *poller) followHead(ctx context.Context, head uint64) (err error) {
+	ctx, span := tracer.Start(ctx, "follow head", trace.WithAttributes(attribute.Int64("polygon.block.head", int64(head))))
+	defer func() { endSpan(span, err) }()
+	return p.catchUp(ctx, head)
+}
+
+// pollOnce processes the blocks produced since the last cycle, up to the latest one.
+// Each cycle is traced as a span, the parent of the spans of its RPC calls.
+func (p *poller) pollOnce(ctx context.Context) (err error) {
+	ctx, span := tracer.Start(ctx, "poll cycle")
+	defer func() { endSpan(span, err) }()
+
+	number, err := p.client.BlockNumber(ctx)
+	if err != nil {
+		return fmt.Errorf("error getting block number: %w", err)
+	}
+	span.SetAttributes(attribute.Int64("polygon.block.head", int64(number)))
+	return p.catchUp(ctx, number)
+}
+
+// catchUp processes, in order, every block after the last processed one up to head.
+// At most maxCatchUp blocks are processed, the oldest ones are skipped when the poller fell further behind.
+func (p *poller) catchUp(ctx context.Context, head uint64) error {
+	from := head
+	if p.last != 0 {
+		from = p.last + 1
+	}
+	if head < from {
+		// The endpoint lags behind the last processed block
+		return nil
+	}
+	if head-from >= uint64(p.maxCatchUp) {
+		skipped := head - from - uint64(p.maxCatchUp) + 1
+		slog.Warn("fell behind, skipping blocks", "behind", head-from+1, "from_block", from, "to_block", from+skipped-1)
+		from += skipped
+	}
+	trace.SpanFromContext(ctx).SetAttributes(blockRangeAttributes(from, head)...)
+
+	blocks := make([]*rpc.Block, head-from+1)
+	errs := make([]error, len(blocks))
+	sem := make(chan struct{}, p.concurrency)
+	var wg sync.WaitGroup
+	for i := range blocks {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			blocks[i], errs[i] = p.client.BlockByNumber(ctx, from+uint64(i), p.fullTx)
+		}(i)
+	}
+	wg.Wait()
+
+	// Blocks are processed in order up to the first failure, the next cycle resumes from there
+	for i, block := range blocks {
+		if errs[i] != nil {
+			return fmt.Errorf("error getting block %d: %w", from+uint64(i), errs[i])
+		}
+		if err := p.process(ctx, block); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// process checks the block for reorganisations, then handles the blocks a reorganisation brought in and the block, in order.
+func (p *poller) process(ctx context.Context, block *rpc.Block) error {
+	number := block.Number.Uint64()
+	reorg, err := p.chain.observe(ctx, block)
+	switch {
+	case errors.Is(err, errReorgTooDeep):
+		slog.Warn("chain reorganisation deeper than the kept headers, following the new chain", "block_number", number)
+	case err != nil:
+		return fmt.Errorf("error checking block %d for reorganisations: %w", number, err)
+	case reorg != nil:
+		slog.Warn("chain reorganisation detected", "block_number", number, "block_hash", block.Hash.String(), "depth", reorg.Depth, "ancestor", reorg.Ancestor,
+			"orphaned", reorg.Orphaned, "new", reorg.New)
+		if p.onReorg != nil {
+			p.onReorg(*reorg)
+		}
+		for _, replacement := range reorg.Blocks {
+			p.handle(ctx, replacement)
+		}
+	}
+	p.handle(ctx, block)
+	p.last = number
+	return nil
+}
+
+// handle logs the block and hands it to onBlock and the activity logger.
+func (p *poller) handle(ctx context.Context, block *rpc.Block) {
+	number := block.Number.Uint64()
+	slog.Info("new block", "block_number", number, "block_hash", block.Hash.String(), "tx_count", block.Transactions.Len())
+	p.health.markBlock(time.Now())
+	if p.onBlock != nil {
+		p.onBlock(block)
+	}
+	if p.activity != nil {
+		if err := p.activity.logBlock(ctx, block); err != nil && ctx.Err() == nil {
+			slog.Warn("error logging block activity", "block_number", number, rpc.ErrorAttr(err))
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rafaribe/polygon-client/rpc"
+)
+
+func TestPollerCatchUp(t *testing.T) {
+	var mu sync.Mutex
+	var requested []uint64
+	// Blocks are served by number, each block hash derived from its number so that blocks link to each other
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     uint64            `json:"id"`
+			Params []json.RawMessage `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("error decoding request: %v", err)
+			return
+		}
+		var number rpc.Quantity
+		if err := json.Unmarshal(req.Params[0], &number); err != nil {
+			t.Errorf("error decoding block number: %v", err)
+			return
+		}
+		mu.Lock()
+		requested = append(requested, number.Uint64())
+		mu.Unlock()
+
+		block := rpc.Block{Number: number, Hash: rpc.Hash{byte(number)}, ParentHash: rpc.Hash{byte(number - 1)}}
+		if err := json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": block}); err != nil {
+			t.Errorf("error writing response: %v", err)
+		}
+	}))
+	defer server.Close()
+	client, err := rpc.NewClient([]string{server.URL})
+	if err != nil {
+		t.Fatalf("NewClient returned unexpected error: %v", err)
+	}
+
+	p := &poller{
+		client:      client,
+		health:      newHealthServer(time.Minute),
+		maxCatchUp:  3,
+		concurrency: 2,
+		// Blocks processed out of order would need their parent to be looked up
+		chain: newChainTracker(2, func(ctx context.Context, hash rpc.Hash) (*rpc.Block, error) {
+			t.Errorf("unexpected parent lookup of %s", hash)
+			return nil, rpc.ErrBlockNotFound
+		}),
+		onReorg: func(e reorgEvent) { t.Errorf("unexpected reorg %s", e) },
+	}
+
+	tests := []struct {
+		head     uint64
+		expected []uint64
+	}{
+		// The first cycle starts at the head
+		{10, []uint64{10}},
+		// Blocks produced between two cycles are fetched in order
+		{12, []uint64{11, 12}},
+		// The endpoint lagging behind is not a reason to fetch again
+		{11, []uint64{}},
+		// Falling further behind than the catch up window skips the oldest blocks
+		{20, []uint64{18, 19, 20}},
+	}
+	for _, test := range tests {
+		requested = []uint64{}
+		if err := p.catchUp(context.Background(), test.head); err != nil {
+			t.Fatalf("catchUp to %d returned unexpected error: %v", test.head, err)
+		}
+		// Blocks are fetched concurrently, in any order
+		sort.Slice(requested, func(i, j int) bool { return requested[i] < requested[j] })
+		if !reflect.DeepEqual(requested, test.expected) {
+			t.Errorf("head %d: expected blocks %v to be fetched, got %v", test.head, test.expected, requested)
+		}
+	}
+	if p.last != 20 {
+		t.Errorf("expected last processed block 20, got %d", p.last)
+	}
+}
+
+func TestPollerProcessesReorgBlocks(t *testing.T) {
+	chain := newTestChain()
+	canonical := chain.extend(nil, 5)
+	var processed []rpc.Hash
+	var reorgs []reorgEvent
+	p := &poller{
+		health:  newHealthServer(time.Minute),
+		chain:   newChainTracker(8, chain.blockByHash),
+		onBlock: func(block *rpc.Block) { processed = append(processed, block.Hash) },
+		onReorg: func(e reorgEvent) { reorgs = append(reorgs, e) },
+	}
+	for _, block := range canonical {
+		if err := p.process(context.Background(), block); err != nil {
+			t.Fatalf("process returned unexpected error on block %d: %v", block.Number, err)
+		}
+	}
+
+	// Blocks 4 and 5 are replaced by a fork of block 3, only its head is fetched
+	fork := chain.extend(canonical[2], 3)
+	if err := p.process(context.Background(), fork[2]); err != nil {
+		t.Fatalf("process returned unexpected error on block %d: %v", fork[2].Number, err)
+	}
+
+	if len(reorgs) != 1 {
+		t.Fatalf("expected a single reorg, got %v", reorgs)
+	}
+	// The new blocks are processed in order, before the fetched head
+	var expected []rpc.Hash
+	for _, block := range append(canonical, fork...) {
+		expected = append(expected, block.Hash)
+	}
+	if !reflect.DeepEqual(processed, expected) {
+		t.Errorf("expected blocks %v to be processed, got %v", expected, processed)
+	}
+	if p.last != 6 {
+		t.Errorf("expected last processed block 6, got %d", p.last)
+	}
+}
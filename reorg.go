@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/rafaribe/polygon-client/rpc"
+)
+
+// header is the part of a block needed to follow the chain.
+type header struct {
+	Number     uint64
+	Hash       rpc.Hash
+	ParentHash rpc.Hash
+}
+
+func headerOf(block *rpc.Block) header {
+	return header{Number: block.Number.Uint64(), Hash: block.Hash, ParentHash: block.ParentHash}
+}
+
+// headerRing keeps the most recent headers of the canonical chain, with consecutive numbers.
+type headerRing struct {
+	headers []header
+	// start is the index of the oldest header, size the number of headers kept
+	start, size int
+}
+
+func newHeaderRing(capacity int) *headerRing {
+	return &headerRing{headers: make([]header, capacity)}
+}
+
+func (r *headerRing) at(i int) header {
+	return r.headers[(r.start+i)%len(r.headers)]
+}
+
+// push appends the header, evicting the oldest one when the ring is full.
+// The header must follow the latest one, see truncate.
+func (r *headerRing) push(h header) {
+	if r.size < len(r.headers) {
+		r.headers[(r.start+r.size)%len(r.headers)] = h
+		r.size++
+		return
+	}
+	r.headers[r.start] = h
+	r.start = (r.start + 1) % len(r.headers)
+}
+
+// latest returns the most recent header, if any.
+func (r *headerRing) latest() (header, bool) {
+	if r.size == 0 {
+		return header{}, false
+	}
+	return r.at(r.size - 1), true
+}
+
+// oldest returns the least recent header, if any.
+func (r *headerRing) oldest() (header, bool) {
+	if r.size == 0 {
+		return header{}, false
+	}
+	return r.at(0), true
+}
+
+// get returns the header with the given number, if it is kept.
+func (r *headerRing) get(number uint64) (header, bool) {
+	oldest, ok := r.oldest()
+	if !ok || number < oldest.Number || number-oldest.Number >= uint64(r.size) {
+		return header{}, false
+	}
+	return r.at(int(number - oldest.Number)), true
+}
+
+// truncate drops the headers above number and returns them, oldest first.
+func (r *headerRing) truncate(number uint64) []header {
+	var dropped []header
+	for r.size > 0 {
+		latest := r.at(r.size - 1)
+		if latest.Number <= number {
+			break
+		}
+		dropped = append([]header{latest}, dropped...)
+		r.size--
+	}
+	return dropped
+}
+
+func (r *headerRing) reset() {
+	r.start, r.size = 0, 0
+}
+
+// reorgEvent describes a chain reorganisation.
+type reorgEvent struct {
+	// Depth is the number of blocks that left the canonical chain
+	Depth int
+	// Ancestor is the number of the most recent block shared by both chains
+	Ancestor uint64
+	// Orphaned holds the hashes of the blocks that left the canonical chain, and New the ones that replaced them, oldest first
+	Orphaned []rpc.Hash
+	New      []rpc.Hash
+	// Blocks holds the new blocks fetched while walking back to the ancestor, oldest first, the observed block excluded
+	Blocks []*rpc.Block
+}
+
+func (e reorgEvent) String() string {
+	return fmt.Sprintf("depth %d after block %d, orphaned %v, new %v", e.Depth, e.Ancestor, e.Orphaned, e.New)
+}
+
+// errReorgTooDeep is returned when no common ancestor is found within the kept headers.
+var errReorgTooDeep = errors.New("no common ancestor within the kept headers")
+
+// chainTracker follows the canonical chain through the blocks it observes and detects reorganisations.
+type chainTracker struct {
+	ring *headerRing
+	// blockByHash fetches the parents needed to link a block to the kept headers
+	blockByHash func(ctx context.Context, hash rpc.Hash) (*rpc.Block, error)
+}
+
+func newChainTracker(depth int, blockByHash func(ctx context.Context, hash rpc.Hash) (*rpc.Block, error)) *chainTracker {
+	return &chainTracker{ring: newHeaderRing(depth), blockByHash: blockByHash}
+}
+
+// observe links the block to the kept headers, walking its parents back to the common ancestor when it does not extend the latest one.
+// It returns the reorganisation the block revealed, if any.
+// When the block cannot be linked to the kept headers they are replaced by the block, and errReorgTooDeep is returned.
+func (t *chainTracker) observe(ctx context.Context, block *rpc.Block) (*reorgEvent, error) {
+	h := headerOf(block)
+	latest, ok := t.ring.latest()
+	oldest, _ := t.ring.oldest()
+	switch {
+	case !ok, h.Number > latest.Number+uint64(len(t.ring.headers)):
+		// Nothing to link to, or too far ahead for the kept headers to matter
+		t.ring.reset()
+		t.ring.push(h)
+		return nil, nil
+	case h.Number <= oldest.Number:
+		// Too old to tell whether it is canonical
+		return nil, nil
+	}
+	if kept, ok := t.ring.get(h.Number); ok && kept.Hash == h.Hash {
+		// Already seen, e.g. from an endpoint lagging behind
+		return nil, nil
+	}
+
+	// Walk back through the parents until one of them is kept
+	branch := []header{h}
+	var fetched []*rpc.Block
+	for {
+		tip := branch[0]
+		parent, ok := t.ring.get(tip.Number - 1)
+		if ok && parent.Hash == tip.ParentHash {
+			break
+		}
+		if tip.Number-1 <= oldest.Number {
+			t.ring.reset()
+			t.ring.push(h)
+			return nil, errReorgTooDeep
+		}
+		block, err := t.blockByHash(ctx, tip.ParentHash)
+		if err != nil {
+			return nil, fmt.Errorf("error getting parent of block %d: %w", tip.Number, err)
+		}
+		branch = append([]header{headerOf(block)}, branch...)
+		fetched = append([]*rpc.Block{block}, fetched...)
+	}
+
+	ancestor := branch[0].Number - 1
+	orphaned := t.ring.truncate(ancestor)
+	for _, h := range branch {
+		t.ring.push(h)
+	}
+	if len(orphaned) == 0 {
+		return nil, nil
+	}
+
+	event := &reorgEvent{Depth: len(orphaned), Ancestor: ancestor, Blocks: fetched}
+	for _, h := range orphaned {
+		event.Orphaned = append(event.Orphaned, h.Hash)
+	}
+	for _, h := range branch {
+		event.New = append(event.New, h.Hash)
+	}
+	return event, nil
+}
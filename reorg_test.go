@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/rafaribe/polygon-client/rpc"
+)
+
+// testChain builds blocks on top of each other, forks branch off an existing block.
+type testChain struct {
+	blocks map[rpc.Hash]*rpc.Block
+	next   byte
+}
+
+func newTestChain() *testChain {
+	return &testChain{blocks: make(map[rpc.Hash]*rpc.Block)}
+}
+
+// extend adds n blocks on top of parent, nil starting at block 1, and returns them.
+func (c *testChain) extend(parent *rpc.Block, n int) []*rpc.Block {
+	var blocks []*rpc.Block
+	for i := 0; i < n; i++ {
+		c.next++
+		block := &rpc.Block{Number: 1, Hash: rpc.Hash{c.next}}
+		if parent != nil {
+			block.Number = parent.Number + 1
+			block.ParentHash = parent.Hash
+		}
+		c.blocks[block.Hash] = block
+		blocks = append(blocks, block)
+		parent = block
+	}
+	return blocks
+}
+
+func (c *testChain) blockByHash(ctx context.Context, hash rpc.Hash) (*rpc.Block, error) {
+	block, ok := c.blocks[hash]
+	if !ok {
+		return nil, rpc.ErrBlockNotFound
+	}
+	return block, nil
+}
+
+func TestChainTrackerDetectsReorg(t *testing.T) {
+	chain := newTestChain()
+	canonical := chain.extend(nil, 5)
+	tracker := newChainTracker(8, chain.blockByHash)
+	for _, block := range canonical {
+		if reorg, err := tracker.observe(context.Background(), block); reorg != nil || err != nil {
+			t.Fatalf("unexpected reorg %v, error %v on block %d", reorg, err, block.Number)
+		}
+	}
+
+	// Blocks 4 and 5 are replaced by a fork of block 3, only its head is observed
+	fork := chain.extend(canonical[2], 3)
+	reorg, err := tracker.observe(context.Background(), fork[2])
+	if err != nil {
+		t.Fatalf("observe returned unexpected error: %v", err)
+	}
+	expected := &reorgEvent{
+		Depth:    2,
+		Ancestor: 3,
+		Orphaned: []rpc.Hash{canonical[3].Hash, canonical[4].Hash},
+		New:      []rpc.Hash{fork[0].Hash, fork[1].Hash, fork[2].Hash},
+		Blocks:   []*rpc.Block{fork[0], fork[1]},
+	}
+	if !reflect.DeepEqual(reorg, expected) {
+		t.Errorf("expected reorg %v, got %v", expected, reorg)
+	}
+
+	// The fork is now canonical, extending it is not a reorg
+	next := chain.extend(fork[2], 1)[0]
+	if reorg, err := tracker.observe(context.Background(), next); reorg != nil || err != nil {
+		t.Errorf("unexpected reorg %v, error %v on block %d", reorg, err, next.Number)
+	}
+}
+
+func TestChainTrackerIgnoresKnownBlocks(t *testing.T) {
+	chain := newTestChain()
+	canonical := chain.extend(nil, 3)
+	tracker := newChainTracker(8, chain.blockByHash)
+	for _, block := range append(canonical, canonical[1], canonical[2]) {
+		if reorg, err := tracker.observe(context.Background(), block); reorg != nil || err != nil {
+			t.Fatalf("unexpected reorg %v, error %v on block %d", reorg, err, block.Number)
+		}
+	}
+}
+
+func TestChainTrackerReorgTooDeep(t *testing.T) {
+	chain := newTestChain()
+	canonical := chain.extend(nil, 6)
+	tracker := newChainTracker(4, chain.blockByHash)
+	for _, block := range canonical {
+		if _, err := tracker.observe(context.Background(), block); err != nil {
+			t.Fatalf("observe returned unexpected error: %v", err)
+		}
+	}
+
+	// The fork starts at block 2, below the 4 kept headers
+	fork := chain.extend(canonical[0], 6)
+	if _, err := tracker.observe(context.Background(), fork[5]); !errors.Is(err, errReorgTooDeep) {
+		t.Fatalf("expected errReorgTooDeep, got %v", err)
+	}
+	next := chain.extend(fork[5], 1)[0]
+	if reorg, err := tracker.observe(context.Background(), next); reorg != nil || err != nil {
+		t.Errorf("expected the new chain to be followed, got reorg %v, error %v", reorg, err)
+	}
+}
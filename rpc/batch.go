@@ -0,0 +1,157 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// DefaultMaxBatchSize is the number of calls sent per HTTP request by clients created without WithMaxBatchSize.
+const DefaultMaxBatchSize = 100
+
+// BatchElem is a single call of a batch request.
+type BatchElem struct {
+	Method string
+	Params []interface{}
+	// Result is where the result is decoded into, it must be a pointer or nil to discard the result
+	Result interface{}
+	// Error is set when this call failed, independently of the other calls of the batch
+	Error error
+}
+
+// WithMaxBatchSize sets the maximum number of calls sent in a single HTTP request, larger batches are split.
+func WithMaxBatchSize(n int) Option {
+	return func(c *Client) {
+		c.maxBatchSize = n
+	}
+}
+
+// BatchDo sends all the calls in as few HTTP requests as possible.
+// The returned error only reports transport failures, the outcome of each call is reported in its Error field.
+// Batches larger than the configured size, or than what the endpoint accepts, are split.
+func (c *Client) BatchDo(ctx context.Context, elems []BatchElem) error {
+	size := c.maxBatchSize
+	if size <= 0 {
+		size = DefaultMaxBatchSize
+	}
+	for start := 0; start < len(elems); start += size {
+		end := start + size
+		if end > len(elems) {
+			end = len(elems)
+		}
+		if err := c.batchDo(ctx, elems[start:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// batchDo sends the calls in a single HTTP request, bisecting the batch when the endpoint rejects its size.
+func (c *Client) batchDo(ctx context.Context, elems []BatchElem) error {
+	first := c.nextIDs(len(elems))
+	reqBody := make([]wireRequest, len(elems))
+	for i, elem := range elems {
+		reqBody[i] = newWireRequest(first+uint64(i), Request{Method: elem.Method, Params: elem.Params})
+	}
+
+	var responses []Response[json.RawMessage]
+	err := c.retry.do(ctx, func() error {
+		_, _, err := c.pool.request(ctx, c.httpClient, reqBody, func(body []byte) error {
+			responses = nil
+			if err := json.Unmarshal(body, &responses); err != nil {
+				return &DecodeError{Method: "batch", Err: err}
+			}
+			return nil
+		})
+		return err
+	})
+	if err != nil && len(elems) > 1 && isBatchTooLarge(err) {
+		half := len(elems) / 2
+		if err := c.batchDo(ctx, elems[:half]); err != nil {
+			return err
+		}
+		return c.batchDo(ctx, elems[half:])
+	}
+	if err != nil {
+		return err
+	}
+
+	// Responses may come back in any order, they are matched to their call by id
+	answered := make([]bool, len(elems))
+	for _, resp := range responses {
+		if resp.Version != "2.0" {
+			return &ProtocolError{Method: "batch", Reason: fmt.Sprintf("unexpected jsonrpc version %q", resp.Version)}
+		}
+		// Calls the endpoint could not parse are answered with a null id, they are reported as unanswered
+		if resp.Error != nil && string(resp.ID) == "null" {
+			continue
+		}
+		id, err := strconv.ParseUint(string(resp.ID), 10, 64)
+		if err != nil || id < first || id-first >= uint64(len(elems)) || answered[id-first] {
+			return &ProtocolError{Method: "batch", Reason: fmt.Sprintf("unexpected id %s", resp.ID)}
+		}
+		elem := &elems[id-first]
+		answered[id-first] = true
+		switch {
+		case resp.Error != nil:
+			elem.Error = resp.Error
+		case elem.Result != nil:
+			if err := json.Unmarshal(resp.Result, elem.Result); err != nil {
+				elem.Error = &DecodeError{Method: elem.Method, Err: err}
+			}
+		}
+	}
+	for i := range elems {
+		if !answered[i] {
+			elems[i].Error = fmt.Errorf("no response to %s in batch", elems[i].Method)
+		}
+	}
+	return nil
+}
+
+// isBatchTooLarge reports whether the endpoint rejected a batch because of its size.
+// Providers do not agree on how to report it, so both the HTTP status and the error message are looked at.
+func isBatchTooLarge(err error) bool {
+	var statusErr *HTTPStatusError
+	if errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusRequestEntityTooLarge {
+		return true
+	}
+
+	var rpcErr *RPCError
+	if errors.As(err, &rpcErr) {
+		message := strings.ToLower(rpcErr.Message)
+		return strings.Contains(message, "batch") &&
+			(strings.Contains(message, "too large") || strings.Contains(message, "limit") || strings.Contains(message, "exceed") || strings.Contains(message, "too many"))
+	}
+	return false
+}
+
+// BlocksByNumber fetches the blocks with the given numbers in batches, in the same order as the numbers.
+func (c *Client) BlocksByNumber(ctx context.Context, numbers []uint64, fullTx bool) ([]*Block, error) {
+	blocks := make([]*Block, len(numbers))
+	elems := make([]BatchElem, len(numbers))
+	for i, number := range numbers {
+		elems[i] = BatchElem{
+			Method: "eth_getBlockByNumber",
+			Params: []interface{}{Quantity(number), fullTx},
+			Result: &blocks[i],
+		}
+	}
+
+	if err := c.BatchDo(ctx, elems); err != nil {
+		return nil, err
+	}
+	for i, elem := range elems {
+		if elem.Error != nil {
+			return nil, fmt.Errorf("error getting block %d: %w", numbers[i], elem.Error)
+		}
+		if blocks[i] == nil {
+			return nil, fmt.Errorf("error getting block %d: %w", numbers[i], ErrBlockNotFound)
+		}
+	}
+	return blocks, nil
+}
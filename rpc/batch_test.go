@@ -0,0 +1,109 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rafaribe/polygon-client/rpc/internal/rpctest"
+)
+
+// batchHandlers echo the first param of eth_echo calls as their result and fail eth_fail calls.
+var batchHandlers = rpctest.Handlers{
+	"eth_echo": func(req rpctest.Request) (interface{}, error) {
+		return req.Params[0], nil
+	},
+	"eth_fail": rpctest.Fail(CodeServerError, "header not found"),
+}
+
+func TestBatchMatchesResponsesByID(t *testing.T) {
+	var counter rpctest.Counter
+	server := rpctest.NewServer(t, batchHandlers, rpctest.WithMaxBatchSize(10), rpctest.WithReversedBatches(), rpctest.WithCounter(&counter))
+	client, err := NewClient([]string{server.URL}, WithHTTPClient(server.Client()))
+	if err != nil {
+		t.Fatalf("NewClient returned unexpected error: %v", err)
+	}
+
+	results := make([]Quantity, 4)
+	elems := []BatchElem{
+		{Method: "eth_echo", Params: []interface{}{Quantity(1)}, Result: &results[0]},
+		{Method: "eth_fail", Params: []interface{}{Quantity(2)}, Result: &results[1]},
+		{Method: "eth_echo", Params: []interface{}{Quantity(3)}, Result: &results[2]},
+		{Method: "eth_echo", Params: []interface{}{"latest"}, Result: &results[3]},
+	}
+	if err := client.BatchDo(context.Background(), elems); err != nil {
+		t.Fatalf("BatchDo returned unexpected error: %v", err)
+	}
+
+	if batchSizes := counter.Batches(); len(batchSizes) != 1 {
+		t.Errorf("expected a single HTTP request, got %d", len(batchSizes))
+	}
+	if elems[0].Error != nil || results[0] != 1 || elems[2].Error != nil || results[2] != 3 {
+		t.Errorf("unexpected results %v, errors %v and %v", results, elems[0].Error, elems[2].Error)
+	}
+	if !errors.Is(elems[1].Error, ErrHeaderNotFound) {
+		t.Errorf("expected header not found error on the failing call, got %v", elems[1].Error)
+	}
+	var decodeErr *DecodeError
+	if !errors.As(elems[3].Error, &decodeErr) || decodeErr.Method != "eth_echo" {
+		t.Errorf("expected a DecodeError of eth_echo on the undecodable result, got %v", elems[3].Error)
+	}
+}
+
+func TestBatchSplits(t *testing.T) {
+	var counter rpctest.Counter
+	server := rpctest.NewServer(t, batchHandlers, rpctest.WithMaxBatchSize(3), rpctest.WithReversedBatches(), rpctest.WithCounter(&counter))
+	client, err := NewClient([]string{server.URL}, WithHTTPClient(server.Client()), WithMaxBatchSize(8))
+	if err != nil {
+		t.Fatalf("NewClient returned unexpected error: %v", err)
+	}
+
+	results := make([]Quantity, 10)
+	elems := make([]BatchElem, len(results))
+	for i := range elems {
+		elems[i] = BatchElem{Method: "eth_echo", Params: []interface{}{Quantity(i)}, Result: &results[i]}
+	}
+	if err := client.BatchDo(context.Background(), elems); err != nil {
+		t.Fatalf("BatchDo returned unexpected error: %v", err)
+	}
+
+	for i, elem := range elems {
+		if elem.Error != nil || results[i] != Quantity(i) {
+			t.Errorf("call %d: unexpected result %d, error %v", i, results[i], elem.Error)
+		}
+	}
+
+	// 8 is rejected and bisected into 4 and 4, each rejected and bisected into 2 and 2, then the remaining 2 fit
+	expected := []int{8, 4, 2, 2, 4, 2, 2, 2}
+	batchSizes := counter.Batches()
+	if len(batchSizes) != len(expected) {
+		t.Fatalf("expected batch sizes %v, got %v", expected, batchSizes)
+	}
+	for i := range expected {
+		if batchSizes[i] != expected[i] {
+			t.Fatalf("expected batch sizes %v, got %v", expected, batchSizes)
+		}
+	}
+}
+
+func TestBatchRejectsUnknownIDs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := w.Write([]byte(`[{"jsonrpc":"2.0","id":1,"result":"0x1"},{"jsonrpc":"2.0","id":1000,"result":"0x2"}]`)); err != nil {
+			t.Errorf("error writing response: %v", err)
+		}
+	}))
+	defer server.Close()
+	client, err := NewClient([]string{server.URL})
+	if err != nil {
+		t.Fatalf("NewClient returned unexpected error: %v", err)
+	}
+
+	elems := []BatchElem{{Method: "eth_blockNumber"}, {Method: "eth_chainId"}}
+	err = client.BatchDo(context.Background(), elems)
+	var protocolErr *ProtocolError
+	if !errors.As(err, &protocolErr) {
+		t.Errorf("expected a ProtocolError, got %v", err)
+	}
+}
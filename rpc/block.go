@@ -0,0 +1,139 @@
+package rpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Block is a block as returned by eth_getBlockByNumber.
+type Block struct {
+	Number           Quantity `json:"number"`
+	Hash             Hash     `json:"hash"`
+	ParentHash       Hash     `json:"parentHash"`
+	Nonce            Bytes    `json:"nonce"`
+	Sha3Uncles       Hash     `json:"sha3Uncles"`
+	LogsBloom        Bytes    `json:"logsBloom"`
+	TransactionsRoot Hash     `json:"transactionsRoot"`
+	StateRoot        Hash     `json:"stateRoot"`
+	Miner            Address  `json:"miner"`
+	Difficulty       *BigInt  `json:"difficulty"`
+	TotalDifficulty  *BigInt  `json:"totalDifficulty"`
+	ExtraData        Bytes    `json:"extraData"`
+	Size             Quantity `json:"size"`
+	GasLimit         Quantity `json:"gasLimit"`
+	GasUsed          Quantity `json:"gasUsed"`
+	MixHash          Hash     `json:"mixHash"`
+	ReceiptsRoot     Hash     `json:"receiptsRoot"`
+	// BaseFeePerGas is nil for blocks before the London hard fork
+	BaseFeePerGas *BigInt `json:"baseFeePerGas,omitempty"`
+	// WithdrawalsRoot is nil for blocks before the Shanghai hard fork, Polygon PoS does not process withdrawals
+	WithdrawalsRoot *Hash        `json:"withdrawalsRoot,omitempty"`
+	Timestamp       time.Time    `json:"-"`
+	Transactions    Transactions `json:"transactions"`
+	Uncles          []Hash       `json:"uncles"`
+}
+
+// blockJSON is the wire form of Block, the timestamp being a quantity of seconds.
+type blockJSON struct {
+	blockFields
+	Timestamp Quantity `json:"timestamp"`
+}
+
+// blockFields has the same fields as Block without its methods, so it can be embedded without recursing.
+type blockFields Block
+
+func (b Block) MarshalJSON() ([]byte, error) {
+	return json.Marshal(blockJSON{
+		blockFields: blockFields(b),
+		Timestamp:   Quantity(b.Timestamp.Unix()),
+	})
+}
+
+func (b *Block) UnmarshalJSON(data []byte) error {
+	var dec blockJSON
+	if err := json.Unmarshal(data, &dec); err != nil {
+		return err
+	}
+	*b = Block(dec.blockFields)
+	b.Timestamp = time.Unix(int64(dec.Timestamp), 0).UTC()
+	return nil
+}
+
+// Transactions holds the transactions of a block, either as full objects or as hashes only,
+// depending on the fullTx flag the block was requested with.
+type Transactions struct {
+	hashes []Hash
+	full   []Transaction
+}
+
+// NewTransactions builds the transaction list of a block from full transaction objects.
+func NewTransactions(full []Transaction) Transactions {
+	return Transactions{full: full}
+}
+
+// NewTransactionHashes builds the transaction list of a block from transaction hashes only.
+func NewTransactionHashes(hashes []Hash) Transactions {
+	return Transactions{hashes: hashes}
+}
+
+// Len returns the number of transactions in the block.
+func (t Transactions) Len() int {
+	if t.full != nil {
+		return len(t.full)
+	}
+	return len(t.hashes)
+}
+
+// Hashes returns the transaction hashes, which are available whichever form the block was requested in.
+func (t Transactions) Hashes() []Hash {
+	if t.full == nil {
+		return t.hashes
+	}
+	hashes := make([]Hash, len(t.full))
+	for i, tx := range t.full {
+		hashes[i] = tx.Hash
+	}
+	return hashes
+}
+
+// Full returns the full transaction objects, ok is false when the block was requested with hashes only.
+// A block without transactions always reports ok.
+func (t Transactions) Full() (txs []Transaction, ok bool) {
+	if t.full == nil && len(t.hashes) > 0 {
+		return nil, false
+	}
+	return t.full, true
+}
+
+func (t Transactions) MarshalJSON() ([]byte, error) {
+	if t.full != nil {
+		return json.Marshal(t.full)
+	}
+	if t.hashes == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal(t.hashes)
+}
+
+func (t *Transactions) UnmarshalJSON(data []byte) error {
+	var items []json.RawMessage
+	if err := json.Unmarshal(data, &items); err != nil {
+		return err
+	}
+	*t = Transactions{}
+	if len(items) == 0 {
+		return nil
+	}
+
+	// The first item tells which form the node answered with, every other item must match it
+	switch first := bytes.TrimLeft(items[0], " \t\r\n"); {
+	case len(first) > 0 && first[0] == '"':
+		return json.Unmarshal(data, &t.hashes)
+	case len(first) > 0 && first[0] == '{':
+		return json.Unmarshal(data, &t.full)
+	default:
+		return fmt.Errorf("invalid transaction %s: expected a hash or an object", items[0])
+	}
+}
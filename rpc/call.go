@@ -0,0 +1,114 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+)
+
+// BlockTag selects the block whose state is read, by number, by name or, as per EIP-1898, by hash.
+// The zero value is the latest block.
+type BlockTag struct {
+	name             string
+	number           uint64
+	byNumber         bool
+	hash             Hash
+	byHash           bool
+	requireCanonical bool
+}
+
+// Named block tags.
+var (
+	LatestBlock    = BlockTag{name: "latest"}
+	PendingBlock   = BlockTag{name: "pending"}
+	SafeBlock      = BlockTag{name: "safe"}
+	FinalizedBlock = BlockTag{name: "finalized"}
+	EarliestBlock  = BlockTag{name: "earliest"}
+)
+
+// BlockNumberTag selects the block with the given number.
+func BlockNumberTag(number uint64) BlockTag {
+	return BlockTag{number: number, byNumber: true}
+}
+
+// BlockHashTag selects the block with the given hash, see EIP-1898.
+// With requireCanonical set the endpoint rejects the query when the block is not part of the canonical chain anymore.
+func BlockHashTag(hash Hash, requireCanonical bool) BlockTag {
+	return BlockTag{hash: hash, byHash: true, requireCanonical: requireCanonical}
+}
+
+// String returns the number, the name or the hash of the block.
+func (t BlockTag) String() string {
+	switch {
+	case t.byHash:
+		return t.hash.String()
+	case t.byNumber:
+		return Quantity(t.number).String()
+	case t.name == "":
+		return LatestBlock.name
+	}
+	return t.name
+}
+
+func (t BlockTag) MarshalJSON() ([]byte, error) {
+	if t.byHash {
+		return json.Marshal(struct {
+			BlockHash        Hash `json:"blockHash"`
+			RequireCanonical bool `json:"requireCanonical,omitempty"`
+		}{t.hash, t.requireCanonical})
+	}
+	return json.Marshal(t.String())
+}
+
+// CallMsg is a message executed by eth_call, against the state of a block and without creating a transaction.
+type CallMsg struct {
+	// From is the sender, nil meaning the zero address
+	From *Address
+	// To is the called contract, nil for a contract creation
+	To *Address
+	// Gas caps the gas used by the call, zero leaving the endpoint's own cap
+	Gas      uint64
+	GasPrice *big.Int
+	Value    *big.Int
+	Data     []byte
+}
+
+func (m CallMsg) MarshalJSON() ([]byte, error) {
+	arg := map[string]interface{}{}
+	if m.From != nil {
+		arg["from"] = *m.From
+	}
+	if m.To != nil {
+		arg["to"] = *m.To
+	}
+	if m.Gas != 0 {
+		arg["gas"] = Quantity(m.Gas)
+	}
+	if m.GasPrice != nil {
+		arg["gasPrice"] = (*BigInt)(m.GasPrice)
+	}
+	if m.Value != nil {
+		arg["value"] = (*BigInt)(m.Value)
+	}
+	if len(m.Data) > 0 {
+		// Newer nodes read input, older ones only know data, both are sent with the same bytes
+		arg["input"] = Bytes(m.Data)
+		arg["data"] = Bytes(m.Data)
+	}
+	return json.Marshal(arg)
+}
+
+// Call executes the message against the state of the block and returns the data it returns.
+// A reverted call returns a *RevertError holding the revert reason or data.
+func (c *Client) Call(ctx context.Context, msg CallMsg, block BlockTag) (Bytes, error) {
+	result, err := Send[Bytes](ctx, c, Request{Method: "eth_call", Params: []interface{}{msg, block}})
+	var rpcErr *RPCError
+	if errors.As(err, &rpcErr) && errors.Is(rpcErr, ErrExecutionReverted) {
+		return nil, newRevertError(rpcErr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
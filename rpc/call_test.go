@@ -0,0 +1,146 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/rafaribe/polygon-client/rpc/internal/rpctest"
+)
+
+func TestBlockTag(t *testing.T) {
+	tests := []struct {
+		tag      BlockTag
+		expected string
+	}{
+		{BlockTag{}, `"latest"`},
+		{LatestBlock, `"latest"`},
+		{FinalizedBlock, `"finalized"`},
+		{BlockNumberTag(0), `"0x0"`},
+		{BlockNumberTag(0x3a9f1c2), `"0x3a9f1c2"`},
+		{BlockHashTag(mustHash(t, testBlockHash), false), `{"blockHash":"` + testBlockHash + `"}`},
+		{BlockHashTag(mustHash(t, testBlockHash), true), `{"blockHash":"` + testBlockHash + `","requireCanonical":true}`},
+	}
+
+	for _, tt := range tests {
+		encoded, err := json.Marshal(tt.tag)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if string(encoded) != tt.expected {
+			t.Errorf("expected %s, got %s", tt.expected, encoded)
+		}
+	}
+}
+
+func TestCall(t *testing.T) {
+	var params json.RawMessage
+	server := rpctest.NewServer(t, rpctest.Handlers{
+		"eth_call": func(req rpctest.Request) (interface{}, error) {
+			encoded, err := json.Marshal(req.Params)
+			params = encoded
+			return json.RawMessage(`"0x0000000000000000000000000000000000000000000000000000000000000006"`), err
+		},
+	})
+
+	client, err := NewClient([]string{server.URL})
+	if err != nil {
+		t.Fatalf("NewClient returned unexpected error: %v", err)
+	}
+	token, _ := HexToAddress("0xc2132d05d31c914a87c6611c10748aeb04b58e8f")
+
+	result, err := client.Call(context.Background(), CallMsg{To: &token, Value: big.NewInt(0), Data: []byte{0x31, 0x3c, 0xe5, 0x67}}, BlockNumberTag(0x3a9f1c2))
+	if err != nil {
+		t.Fatalf("Call returned unexpected error: %v", err)
+	}
+
+	expected := `[{"data":"0x313ce567","input":"0x313ce567","to":"0xc2132d05d31c914a87c6611c10748aeb04b58e8f","value":"0x0"},"0x3a9f1c2"]`
+	if string(params) != expected {
+		t.Errorf("expected params %s, got %s", expected, params)
+	}
+	if new(big.Int).SetBytes(result).Int64() != 6 {
+		t.Errorf("expected 6, got %s", result)
+	}
+}
+
+func TestCallRevert(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       *rpctest.Error
+		reason    string
+		panicCode int64
+		// data is the selector the revert data starts with
+		data string
+	}{
+		{
+			name: "reason string",
+			err: &rpctest.Error{Code: 3, Message: "execution reverted: Insufficient balance", Data: "0x08c379a0" +
+				"0000000000000000000000000000000000000000000000000000000000000020" +
+				"0000000000000000000000000000000000000000000000000000000000000014" +
+				"496e73756666696369656e742062616c616e6365000000000000000000000000"},
+			reason: "Insufficient balance",
+			data:   "0x08c379a0",
+		},
+		{
+			name:      "panic",
+			err:       &rpctest.Error{Code: 3, Message: "execution reverted", Data: "0x4e487b710000000000000000000000000000000000000000000000000000000000000011"},
+			panicCode: 0x11,
+			data:      "0x4e487b71",
+		},
+		{
+			name: "custom error",
+			err:  &rpctest.Error{Code: 3, Message: "execution reverted", Data: "Reverted 0xcf479181"},
+			data: "0xcf479181",
+		},
+		{
+			name:   "reason in message only",
+			err:    &rpctest.Error{Code: CodeServerError, Message: "execution reverted: Ownable: caller is not the owner"},
+			reason: "Ownable: caller is not the owner",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var firstCalls, secondCalls rpctest.Counter
+			first := rpctest.NewServer(t, rpctest.Handlers{
+				"eth_call": func(rpctest.Request) (interface{}, error) { return nil, tt.err },
+			}, rpctest.WithCounter(&firstCalls))
+			second := rpctest.NewServer(t, rpctest.Handlers{"eth_call": rpctest.Result(`"0x"`)}, rpctest.WithCounter(&secondCalls))
+			client, err := NewClient([]string{first.URL, second.URL})
+			if err != nil {
+				t.Fatalf("NewClient returned unexpected error: %v", err)
+			}
+
+			_, err = client.Call(context.Background(), CallMsg{}, LatestBlock)
+			var revertErr *RevertError
+			if !errors.As(err, &revertErr) {
+				t.Fatalf("expected a RevertError, got %v", err)
+			}
+			if !errors.Is(err, ErrExecutionReverted) {
+				t.Errorf("expected the error to match ErrExecutionReverted")
+			}
+			if revertErr.Reason != tt.reason {
+				t.Errorf("expected reason %q, got %q", tt.reason, revertErr.Reason)
+			}
+			if (revertErr.PanicCode == nil) != (tt.panicCode == 0) || (revertErr.PanicCode != nil && revertErr.PanicCode.Int64() != tt.panicCode) {
+				t.Errorf("expected panic code %d, got %v", tt.panicCode, revertErr.PanicCode)
+			}
+			selector := ""
+			if len(revertErr.Data) >= 4 {
+				selector = Bytes(revertErr.Data[:4]).String()
+			}
+			if selector != tt.data {
+				t.Errorf("expected data starting with %s, got %s", tt.data, revertErr.Data)
+			}
+			// A revert does not depend on the endpoint, neither failing over nor retrying would change it
+			if firstCalls.Calls("eth_call") != 1 || secondCalls.Calls("eth_call") != 0 {
+				t.Errorf("expected a single call to the first endpoint, got %d and %d", firstCalls.Calls("eth_call"), secondCalls.Calls("eth_call"))
+			}
+			if status := client.Endpoints()[0]; !status.Healthy || status.ConsecutiveFailures != 0 {
+				t.Errorf("expected the endpoint to stay healthy, got %+v", status)
+			}
+		})
+	}
+}
@@ -0,0 +1,172 @@
+// Package rpc is a typed JSON-RPC client for Polygon PoS nodes.
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Client sends JSON-RPC requests to a pool of endpoints, failing over and retrying as needed.
+type Client struct {
+	httpClient *http.Client
+	pool       *endpointPool
+	retry      RetryPolicy
+	// maxBatchSize is the maximum number of calls sent in a single HTTP request
+	maxBatchSize int
+	// maxLogRange is the widest block range of a single eth_getLogs call made by FilterLogs
+	maxLogRange uint64
+	// logConcurrency is the number of eth_getLogs calls FilterLogs makes in parallel
+	logConcurrency int
+	// pollInterval is how often subscriptions falling back to HTTP poll for new data
+	pollInterval time.Duration
+	// lastID is the id of the last request sent, ids are unique and increasing over the lifetime of the client
+	lastID atomic.Uint64
+	// noBlockReceipts is set once eth_getBlockReceipts was reported as unsupported, receipts are then fetched one by one
+	noBlockReceipts atomic.Bool
+
+	wsURL string
+	wsMu  sync.Mutex
+	ws    *wsConn
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithHTTPClient sets the HTTP client used for every request, its Timeout applies to each attempt.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithRetryPolicy sets how failed requests are retried.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *Client) {
+		c.retry = policy
+	}
+}
+
+// WithEndpointHealth sets after how many consecutive failures an endpoint is taken out of rotation, and for how long.
+func WithEndpointHealth(maxFailures int, cooldown time.Duration) Option {
+	return func(c *Client) {
+		c.pool.maxFailures = maxFailures
+		c.pool.cooldown = cooldown
+	}
+}
+
+// Observer is notified of every request a Client sends to an endpoint, e.g. to record metrics.
+type Observer interface {
+	// ObserveRequest is called once per endpoint tried, err is nil when the endpoint answered successfully.
+	// Batches are reported with the method name batch, endpoints by their name as in EndpointStatus.
+	ObserveRequest(method, endpoint string, latency time.Duration, err error)
+}
+
+// WithObserver sets the observer notified of every request.
+func WithObserver(o Observer) Option {
+	return func(c *Client) {
+		c.pool.observer = o
+	}
+}
+
+// EndpointStatus is a snapshot of the health of an endpoint.
+type EndpointStatus struct {
+	URL string
+	// Name identifies the endpoint without the API key its URL may hold, it is safe to log or export
+	Name    string
+	Healthy bool
+	// ConsecutiveFailures is reset on every successful request
+	ConsecutiveFailures int
+	// Latency is the moving average of successful request durations
+	Latency time.Duration
+	// LastBlock is the highest block number the endpoint reported
+	LastBlock uint64
+}
+
+// Endpoints returns the health of every endpoint, in the order they were given.
+func (c *Client) Endpoints() []EndpointStatus {
+	return c.pool.status()
+}
+
+// NewClient creates a client for the given endpoint URLs, requests are routed to the best healthy one.
+func NewClient(endpoints []string, opts ...Option) (*Client, error) {
+	if len(endpoints) == 0 {
+		return nil, errors.New("at least one endpoint is required")
+	}
+
+	c := &Client{
+		httpClient: &http.Client{Timeout: time.Second * 5},
+		pool:       newEndpointPool(endpoints, 3, time.Second*30),
+		retry:      DefaultRetryPolicy,
+
+		maxBatchSize:   DefaultMaxBatchSize,
+		maxLogRange:    DefaultMaxLogRange,
+		logConcurrency: DefaultLogConcurrency,
+		pollInterval:   time.Second * 2,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// Do calls the JSON-RPC method with the given params and decodes its result into result.
+// Send is the typed alternative, decoding the result into the requested type.
+func (c *Client) Do(ctx context.Context, method string, result interface{}, params ...interface{}) error {
+	raw, err := Send[json.RawMessage](ctx, c, Request{Method: method, Params: params})
+	if err != nil {
+		return err
+	}
+	if result != nil {
+		if err := json.Unmarshal(raw, result); err != nil {
+			return &DecodeError{Method: method, Err: err}
+		}
+	}
+	return nil
+}
+
+// nextIDs reserves n consecutive request ids and returns the first one.
+func (c *Client) nextIDs(n int) uint64 {
+	return c.lastID.Add(uint64(n)) - uint64(n) + 1
+}
+
+// BlockNumber returns the number of the most recent block.
+func (c *Client) BlockNumber(ctx context.Context) (uint64, error) {
+	result, endpoint, err := send[Quantity](ctx, c, Request{Method: "eth_blockNumber"})
+	if err != nil {
+		return 0, err
+	}
+
+	c.pool.observeBlock(endpoint, result.Uint64())
+	return result.Uint64(), nil
+}
+
+// BlockByNumber returns the block with the given number, with full transaction objects when fullTx is set.
+// It returns ErrBlockNotFound when the endpoint does not know the block yet.
+func (c *Client) BlockByNumber(ctx context.Context, number uint64, fullTx bool) (*Block, error) {
+	block, err := Send[*Block](ctx, c, Request{Method: "eth_getBlockByNumber", Params: []interface{}{Quantity(number), fullTx}})
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, ErrBlockNotFound
+	}
+	return block, nil
+}
+
+// BlockByHash returns the block with the given hash, with full transaction objects when fullTx is set.
+// It returns ErrBlockNotFound when the endpoint does not know the block.
+func (c *Client) BlockByHash(ctx context.Context, hash Hash, fullTx bool) (*Block, error) {
+	block, err := Send[*Block](ctx, c, Request{Method: "eth_getBlockByHash", Params: []interface{}{hash, fullTx}})
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, ErrBlockNotFound
+	}
+	return block, nil
+}
@@ -0,0 +1,315 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/rafaribe/polygon-client/rpc/internal/rpctest"
+)
+
+func TestClientBlockNumber(t *testing.T) {
+	server := rpctest.NewServer(t, rpctest.Handlers{
+		"eth_blockNumber": rpctest.Result(`"0x28bb63f"`),
+	})
+
+	client, err := NewClient([]string{server.URL}, WithHTTPClient(server.Client()))
+	if err != nil {
+		t.Fatalf("NewClient returned unexpected error: %v", err)
+	}
+
+	number, err := client.BlockNumber(context.Background())
+	if err != nil {
+		t.Fatalf("BlockNumber returned unexpected error: %v", err)
+	}
+	if number != 0x28bb63f {
+		t.Errorf("expected block number %d, got %d", 0x28bb63f, number)
+	}
+}
+
+func TestClientBlockByNumber(t *testing.T) {
+	server := rpctest.NewServer(t, rpctest.Handlers{
+		"eth_getBlockByNumber": rpctest.Result(`{"number":"0x134e82a","hash":"0xe1efb3e3e0e76e7578a6c9216755bf25d22cb0c43dff9aff4f62de507e846d4f","parentHash":"0xa69903bcde35192f34a89e913c67832b88ecc408cf7c376916e32f8c4e9db9a9","transactions":[{"hash":"0x50e7d90746c62550262e436912b3e6e7d55cdcfbbfa53d7299f4c68c48ddf050","from":"0x0000000000000000000000000000000000000000","value":"0x0"}],"uncles":[]}`),
+	})
+
+	client, err := NewClient([]string{server.URL}, WithHTTPClient(server.Client()))
+	if err != nil {
+		t.Fatalf("NewClient returned unexpected error: %v", err)
+	}
+
+	block, err := client.BlockByNumber(context.Background(), 0x134e82a, true)
+	if err != nil {
+		t.Fatalf("BlockByNumber returned unexpected error: %v", err)
+	}
+	if block.Hash.String() != "0xe1efb3e3e0e76e7578a6c9216755bf25d22cb0c43dff9aff4f62de507e846d4f" {
+		t.Errorf("unexpected block hash %s", block.Hash)
+	}
+	txs, ok := block.Transactions.Full()
+	if !ok || len(txs) != 1 || txs[0].Hash.String() != "0x50e7d90746c62550262e436912b3e6e7d55cdcfbbfa53d7299f4c68c48ddf050" {
+		t.Errorf("unexpected transactions %+v", txs)
+	}
+	if hashes := block.Transactions.Hashes(); len(hashes) != 1 || hashes[0] != txs[0].Hash {
+		t.Errorf("unexpected transaction hashes %v", hashes)
+	}
+}
+
+func TestClientBlockNotFound(t *testing.T) {
+	server := rpctest.NewServer(t, rpctest.Handlers{
+		"eth_getBlockByNumber": rpctest.Result(`null`),
+		"eth_getBlockByHash":   rpctest.Result(`null`),
+	})
+
+	client, err := NewClient([]string{server.URL}, WithHTTPClient(server.Client()))
+	if err != nil {
+		t.Fatalf("NewClient returned unexpected error: %v", err)
+	}
+
+	if _, err := client.BlockByNumber(context.Background(), 1, true); !errors.Is(err, ErrBlockNotFound) {
+		t.Errorf("expected ErrBlockNotFound, got %v", err)
+	}
+	if _, err := client.BlockByHash(context.Background(), Hash{1}, false); !errors.Is(err, ErrBlockNotFound) {
+		t.Errorf("expected ErrBlockNotFound, got %v", err)
+	}
+}
+
+func TestClientRequestIDs(t *testing.T) {
+	var ids []uint64
+	server := rpctest.NewServer(t, rpctest.Handlers{
+		"eth_blockNumber": func(req rpctest.Request) (interface{}, error) {
+			var id uint64
+			if err := json.Unmarshal(req.ID, &id); err != nil {
+				t.Errorf("error decoding request id: %v", err)
+			}
+			ids = append(ids, id)
+			return Quantity(1), nil
+		},
+	})
+
+	client, err := NewClient([]string{server.URL})
+	if err != nil {
+		t.Fatalf("NewClient returned unexpected error: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := client.BlockNumber(context.Background()); err != nil {
+			t.Fatalf("BlockNumber returned unexpected error: %v", err)
+		}
+	}
+
+	if len(ids) != 3 || ids[0] != 1 || ids[1] != 2 || ids[2] != 3 {
+		t.Errorf("expected ids [1 2 3], got %v", ids)
+	}
+}
+
+func TestClientProtocolError(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+	}{
+		{"mismatched id", `{"jsonrpc":"2.0","id":42,"result":"0x1"}`},
+		{"missing id", `{"jsonrpc":"2.0","result":"0x1"}`},
+		{"wrong version", `{"jsonrpc":"1.0","id":1,"result":"0x1"}`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if _, err := w.Write([]byte(test.response)); err != nil {
+					t.Errorf("error writing response: %v", err)
+				}
+			}))
+			defer server.Close()
+
+			client, err := NewClient([]string{server.URL}, WithRetryPolicy(RetryPolicy{MaxAttempts: 1}))
+			if err != nil {
+				t.Fatalf("NewClient returned unexpected error: %v", err)
+			}
+
+			_, err = client.BlockNumber(context.Background())
+			var protocolErr *ProtocolError
+			if !errors.As(err, &protocolErr) {
+				t.Fatalf("expected a ProtocolError, got %v", err)
+			}
+			if protocolErr.Method != "eth_blockNumber" {
+				t.Errorf("expected method eth_blockNumber, got %s", protocolErr.Method)
+			}
+		})
+	}
+}
+
+// recordingObserver records the method and outcome of every observed request.
+type recordingObserver struct {
+	requests  []string
+	endpoints []string
+}
+
+func (o *recordingObserver) ObserveRequest(method, endpoint string, latency time.Duration, err error) {
+	o.requests = append(o.requests, fmt.Sprintf("%s %t", method, err == nil))
+	o.endpoints = append(o.endpoints, endpoint)
+}
+
+func TestClientObserver(t *testing.T) {
+	server := rpctest.NewServer(t, rpctest.Handlers{
+		"eth_blockNumber": rpctest.Result(`"0x28bb63f"`),
+		"eth_fail":        rpctest.Fail(CodeServerError, "header not found"),
+	})
+	observer := &recordingObserver{}
+	client, err := NewClient([]string{server.URL}, WithObserver(observer), WithRetryPolicy(RetryPolicy{MaxAttempts: 1}))
+	if err != nil {
+		t.Fatalf("NewClient returned unexpected error: %v", err)
+	}
+
+	if _, err := client.BlockNumber(context.Background()); err != nil {
+		t.Fatalf("BlockNumber returned unexpected error: %v", err)
+	}
+	if err := client.Do(context.Background(), "eth_fail", nil); err == nil {
+		t.Fatalf("expected eth_fail to fail")
+	}
+
+	expected := []string{"eth_blockNumber true", "eth_fail false"}
+	if !reflect.DeepEqual(observer.requests, expected) {
+		t.Errorf("expected observed requests %v, got %v", expected, observer.requests)
+	}
+	if status := client.Endpoints(); len(status) != 1 || status[0].LastBlock != 0x28bb63f || status[0].ConsecutiveFailures != 1 {
+		t.Errorf("unexpected endpoint status %+v", status)
+	}
+}
+
+func TestClientTracing(t *testing.T) {
+	server := rpctest.NewServer(t, rpctest.Handlers{
+		"eth_blockNumber": rpctest.Result(`"0x28bb63f"`),
+	})
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	client, err := NewClient([]string{server.URL}, WithTracerProvider(tp), WithRetryPolicy(RetryPolicy{MaxAttempts: 1}))
+	if err != nil {
+		t.Fatalf("NewClient returned unexpected error: %v", err)
+	}
+
+	ctx, parent := tp.Tracer("test").Start(context.Background(), "poll cycle")
+	if _, err := client.BlockNumber(ctx); err != nil {
+		t.Fatalf("BlockNumber returned unexpected error: %v", err)
+	}
+	if err := client.Do(ctx, "eth_unknown", nil); err == nil {
+		t.Fatalf("expected eth_unknown to fail")
+	}
+	parent.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 3 {
+		t.Fatalf("expected 3 spans, got %d", len(spans))
+	}
+	for i, expected := range []struct {
+		name  string
+		attrs map[string]string
+	}{
+		{"eth_blockNumber", map[string]string{"rpc.method": "eth_blockNumber", "rpc.jsonrpc.request_id": "1", "url.full": server.URL}},
+		{"eth_unknown", map[string]string{"rpc.jsonrpc.request_id": "2", "error.type": "jsonrpc", "rpc.jsonrpc.error_code": "-32601"}},
+	} {
+		span := spans[i]
+		if span.Name() != expected.name {
+			t.Errorf("expected span %s, got %s", expected.name, span.Name())
+		}
+		if span.Parent().SpanID() != parent.SpanContext().SpanID() {
+			t.Errorf("expected span %s to be a child of the poll cycle", span.Name())
+		}
+		attrs := make(map[string]string)
+		for _, attr := range span.Attributes() {
+			attrs[string(attr.Key)] = attr.Value.Emit()
+		}
+		for key, value := range expected.attrs {
+			if attrs[key] != value {
+				t.Errorf("expected span %s attribute %s=%s, got %q", span.Name(), key, value, attrs[key])
+			}
+		}
+	}
+	if !hasAttribute(spans[0].Attributes(), "http.response.body.size") {
+		t.Errorf("expected eth_blockNumber span to record the response size")
+	}
+	if spans[1].Status().Code != codes.Error {
+		t.Errorf("expected eth_unknown span to have an error status, got %v", spans[1].Status())
+	}
+}
+
+func TestClientRedactsAPIKeys(t *testing.T) {
+	const key = "0123456789abcdef"
+	server := rpctest.NewServer(t, rpctest.Handlers{"eth_fail": rpctest.Fail(CodeServerError, "header not found")})
+	var logs bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+	observer := &recordingObserver{}
+	recorder := tracetest.NewSpanRecorder()
+
+	// The first two endpoints share a host, the last one cannot be reached and its transport error carries the URL it was posted to
+	client, err := NewClient([]string{server.URL + "/v2/" + key, server.URL + "/v3/" + key, "http://127.0.0.1:1/v3/" + key},
+		WithObserver(observer),
+		WithTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))),
+		WithEndpointHealth(1, time.Minute),
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 1}),
+	)
+	if err != nil {
+		t.Fatalf("NewClient returned unexpected error: %v", err)
+	}
+	err = client.Do(context.Background(), "eth_fail", nil)
+	if err == nil {
+		t.Fatalf("expected eth_fail to fail")
+	}
+
+	outputs := map[string]string{
+		"error":    err.Error(),
+		"logs":     logs.String(),
+		"observer": fmt.Sprint(observer.endpoints),
+	}
+	for _, status := range client.Endpoints() {
+		outputs["endpoint names"] += status.Name
+	}
+	var spanEndpoints []string
+	for _, span := range recorder.Ended() {
+		for _, attr := range span.Attributes() {
+			if attr.Key == "url.full" {
+				spanEndpoints = append(spanEndpoints, attr.Value.Emit())
+			}
+		}
+		outputs["span attributes"] += fmt.Sprint(span.Attributes())
+		for _, event := range span.Events() {
+			outputs["span events"] += fmt.Sprint(event.Attributes)
+		}
+		outputs["span status"] += span.Status().Description
+	}
+	for output, value := range outputs {
+		if strings.Contains(value, key) {
+			t.Errorf("expected the API key to be redacted from the %s, got %s", output, value)
+		}
+	}
+	expected := []string{server.URL + "#1", server.URL + "#2", "http://127.0.0.1:1"}
+	if !reflect.DeepEqual(observer.endpoints, expected) {
+		t.Errorf("expected observed endpoints %v, got %v", expected, observer.endpoints)
+	}
+	// Spans name endpoints the same way so that they can be matched with metrics and logs
+	if !reflect.DeepEqual(spanEndpoints, expected) {
+		t.Errorf("expected span endpoints %v, got %v", expected, spanEndpoints)
+	}
+}
+
+func hasAttribute(attrs []attribute.KeyValue, key string) bool {
+	for _, attr := range attrs {
+		if string(attr.Key) == key {
+			return true
+		}
+	}
+	return false
+}
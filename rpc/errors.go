@@ -0,0 +1,231 @@
+package rpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Well-known JSON-RPC error codes, see https://eips.ethereum.org/EIPS/eip-1474#error-codes
+const (
+	CodeParseError        = -32700
+	CodeInvalidRequest    = -32600
+	CodeMethodNotFound    = -32601
+	CodeInvalidParams     = -32602
+	CodeInternalError     = -32603
+	CodeServerError       = -32000
+	CodeLimitExceeded     = -32005
+	CodeExecutionReverted = 3
+)
+
+// Sentinel errors matched by RPCError and HTTPStatusError through errors.Is.
+var (
+	ErrMethodNotFound    = errors.New("method not found")
+	ErrRateLimited       = errors.New("rate limited")
+	ErrHeaderNotFound    = errors.New("header not found")
+	ErrExecutionReverted = errors.New("execution reverted")
+)
+
+// ErrBlockNotFound is returned when the endpoint has no block matching the query.
+var ErrBlockNotFound = errors.New("block not found")
+
+// ErrReceiptNotFound is returned when the endpoint has no receipt for a transaction, because it is unknown or still pending.
+var ErrReceiptNotFound = errors.New("receipt not found")
+
+// ProtocolError is returned when a response does not follow the JSON-RPC 2.0 protocol, e.g. when it echoes another request id.
+type ProtocolError struct {
+	Method string
+	Reason string
+}
+
+func (e *ProtocolError) Error() string {
+	return fmt.Sprintf("JSON-RPC protocol error in %s response: %s", e.Method, e.Reason)
+}
+
+// DecodeError is returned when a response cannot be decoded.
+type DecodeError struct {
+	Method string
+	Err    error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("error unmarshalling %s response: %v", e.Method, e.Err)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// ClassifyError returns the type of a request error, one of transport, http_status, jsonrpc, protocol or decode,
+// along with the HTTP status or JSON-RPC error code when there is one.
+func ClassifyError(err error) (kind, code string) {
+	var statusErr *HTTPStatusError
+	var rpcErr *RPCError
+	var protocolErr *ProtocolError
+	var decodeErr *DecodeError
+	switch {
+	case errors.As(err, &statusErr):
+		return "http_status", strconv.Itoa(statusErr.StatusCode)
+	case errors.As(err, &rpcErr):
+		return "jsonrpc", strconv.Itoa(rpcErr.Code)
+	case errors.As(err, &protocolErr):
+		return "protocol", ""
+	case errors.As(err, &decodeErr):
+		return "decode", ""
+	}
+	return "transport", ""
+}
+
+// ErrorAttr describes a request error for structured logs, along with its classification.
+// It returns an empty attribute, which handlers omit, when err is nil.
+func ErrorAttr(err error) slog.Attr {
+	if err == nil {
+		return slog.Attr{}
+	}
+	kind, code := ClassifyError(err)
+	attrs := []any{slog.String("message", err.Error()), slog.String("type", kind)}
+	if code != "" {
+		attrs = append(attrs, slog.String("code", code))
+	}
+	return slog.Group("error", attrs...)
+}
+
+// RPCError is the error object of a JSON-RPC response.
+type RPCError struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+func (e *RPCError) Error() string {
+	if len(e.Data) > 0 {
+		return fmt.Sprintf("JSON-RPC error %d: %s (data: %s)", e.Code, e.Message, e.Data)
+	}
+	return fmt.Sprintf("JSON-RPC error %d: %s", e.Code, e.Message)
+}
+
+// Is reports whether the error matches one of the well-known sentinel errors.
+// Nodes are not consistent in their codes, so the message is looked at for server errors.
+func (e *RPCError) Is(target error) bool {
+	message := strings.ToLower(e.Message)
+	switch target {
+	case ErrMethodNotFound:
+		return e.Code == CodeMethodNotFound
+	case ErrRateLimited:
+		return e.Code == CodeLimitExceeded || strings.Contains(message, "rate limit") || strings.Contains(message, "too many requests")
+	case ErrHeaderNotFound:
+		// Blocks selected by hash, see EIP-1898, are reported differently
+		return e.Code == CodeServerError && (strings.Contains(message, "header not found") || strings.Contains(message, "header for hash not found"))
+	case ErrExecutionReverted:
+		return e.Code == CodeExecutionReverted || strings.HasPrefix(message, "execution reverted")
+	}
+	return false
+}
+
+// HTTPStatusError is returned when the endpoint answers with a non 2xx HTTP status.
+type HTTPStatusError struct {
+	StatusCode int
+	Status     string
+	// Body holds the beginning of the response body, providers usually explain the failure there
+	Body string
+	// RetryAfter is the delay requested by the Retry-After header, zero when absent
+	RetryAfter time.Duration
+}
+
+func (e *HTTPStatusError) Error() string {
+	if e.Body == "" {
+		return fmt.Sprintf("HTTP status %s", e.Status)
+	}
+	return fmt.Sprintf("HTTP status %s: %s", e.Status, e.Body)
+}
+
+// Is reports whether the error matches one of the well-known sentinel errors.
+func (e *HTTPStatusError) Is(target error) bool {
+	return target == ErrRateLimited && e.StatusCode == http.StatusTooManyRequests
+}
+
+// Selectors of the revert data Solidity encodes for require and revert statements with a message, Error(string), and for panics, Panic(uint256).
+var (
+	errorSelector = []byte{0x08, 0xc3, 0x79, 0xa0}
+	panicSelector = []byte{0x4e, 0x48, 0x7b, 0x71}
+)
+
+// RevertError is returned by Call when the execution reverts.
+// It matches ErrExecutionReverted through errors.Is.
+type RevertError struct {
+	// Reason is the message of a require or revert statement, empty when the contract reverted without one or with a custom error
+	Reason string
+	// PanicCode is set when the contract panicked, 0x11 for an arithmetic overflow for instance
+	PanicCode *big.Int
+	// Data is the raw revert data, custom errors are decoded from it with the ABI of the contract
+	Data Bytes
+	// Err is the error returned by the endpoint
+	Err *RPCError
+}
+
+// newRevertError decodes the revert data the endpoint returned along with the error.
+func newRevertError(rpcErr *RPCError) *RevertError {
+	e := &RevertError{Err: rpcErr}
+	var data string
+	if json.Unmarshal(rpcErr.Data, &data) == nil {
+		// Some nodes describe the data before it
+		if decoded, err := decodeData([]byte(strings.TrimPrefix(data, "Reverted "))); err == nil {
+			e.Data = decoded
+		}
+	}
+
+	switch {
+	case bytes.HasPrefix(e.Data, errorSelector):
+		e.Reason, _ = unpackString(e.Data[len(errorSelector):])
+	case bytes.HasPrefix(e.Data, panicSelector) && len(e.Data) == len(panicSelector)+32:
+		e.PanicCode = new(big.Int).SetBytes(e.Data[len(panicSelector):])
+	case len(e.Data) == 0:
+		// Without data the message is all there is to go by
+		if reason, ok := strings.CutPrefix(rpcErr.Message, "execution reverted: "); ok {
+			e.Reason = reason
+		}
+	}
+	return e
+}
+
+func (e *RevertError) Error() string {
+	switch {
+	case e.Reason != "":
+		return "execution reverted: " + e.Reason
+	case e.PanicCode != nil:
+		return "execution reverted: panic 0x" + e.PanicCode.Text(16)
+	case len(e.Data) > 0:
+		return fmt.Sprintf("execution reverted (data: %s)", e.Data)
+	}
+	return "execution reverted"
+}
+
+func (e *RevertError) Unwrap() error {
+	return e.Err
+}
+
+// unpackString decodes the ABI encoding of a single string.
+// It reports false when the data is malformed.
+func unpackString(data []byte) (string, bool) {
+	const word = 32
+	if len(data) < 2*word {
+		return "", false
+	}
+	offset := new(big.Int).SetBytes(data[:word])
+	if !offset.IsUint64() || offset.Uint64() > uint64(len(data)-2*word) {
+		return "", false
+	}
+	start := offset.Uint64() + word
+	length := new(big.Int).SetBytes(data[start-word : start])
+	if !length.IsUint64() || length.Uint64() > uint64(len(data))-start {
+		return "", false
+	}
+	return string(data[start : start+length.Uint64()]), true
+}
@@ -0,0 +1,233 @@
+// Package rpctest serves JSON-RPC over HTTP for tests, dispatching every call to the handler of its method.
+package rpctest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// Request is a call received by the server.
+type Request struct {
+	Version string            `json:"jsonrpc"`
+	ID      json.RawMessage   `json:"id"`
+	Method  string            `json:"method"`
+	Params  []json.RawMessage `json:"params"`
+}
+
+// Param decodes the i-th parameter of the call into v.
+func (r Request) Param(i int, v interface{}) error {
+	if i >= len(r.Params) {
+		return fmt.Errorf("%s has %d params, expected at least %d", r.Method, len(r.Params), i+1)
+	}
+	return json.Unmarshal(r.Params[i], v)
+}
+
+// Handler answers a call with its result, marshalled to JSON, or with an error.
+// An *Error is sent as the JSON-RPC error object of the response, any other error as an internal error.
+type Handler func(req Request) (interface{}, error)
+
+// Handlers maps methods to their handler.
+type Handlers map[string]Handler
+
+// Error is a JSON-RPC error object.
+type Error struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("JSON-RPC error %d: %s", e.Code, e.Message)
+}
+
+// Result returns a handler answering every call with the given JSON result.
+func Result(raw string) Handler {
+	return func(Request) (interface{}, error) {
+		return json.RawMessage(raw), nil
+	}
+}
+
+// Fail returns a handler answering every call with the given JSON-RPC error.
+func Fail(code int, message string) Handler {
+	return func(Request) (interface{}, error) {
+		return nil, &Error{Code: code, Message: message}
+	}
+}
+
+// Counter counts the calls received by a server, by method, and the size of the batches they came in.
+type Counter struct {
+	mu      sync.Mutex
+	calls   map[string]int
+	batches []int
+}
+
+// Calls returns the number of calls of the method.
+func (c *Counter) Calls(method string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.calls[method]
+}
+
+// Batches returns the number of calls of every batch, in the order they were received.
+func (c *Counter) Batches() []int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]int(nil), c.batches...)
+}
+
+func (c *Counter) add(method string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.calls == nil {
+		c.calls = make(map[string]int)
+	}
+	c.calls[method]++
+}
+
+func (c *Counter) addBatch(size int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.batches = append(c.batches, size)
+}
+
+// Option configures how a server answers.
+type Option func(*server)
+
+// WithMaxBatchSize rejects batches of more than n calls as a whole, as most providers do.
+func WithMaxBatchSize(n int) Option {
+	return func(s *server) {
+		s.maxBatchSize = n
+	}
+}
+
+// WithReversedBatches answers the calls of a batch in reverse order, clients have to match responses by id.
+func WithReversedBatches() Option {
+	return func(s *server) {
+		s.reversed = true
+	}
+}
+
+// WithCounter counts the calls received by the server in c.
+func WithCounter(c *Counter) Option {
+	return func(s *server) {
+		s.counter = c
+	}
+}
+
+type server struct {
+	handlers     Handlers
+	maxBatchSize int
+	reversed     bool
+	counter      *Counter
+}
+
+// response is the envelope of a JSON-RPC response.
+type response struct {
+	Version string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+// Dispatch answers a single call or a batch of calls, encoded in body, with the handlers of their methods.
+// Calls of methods without a handler are answered with a method not found error.
+func (h Handlers) Dispatch(body []byte) ([]byte, error) {
+	return (&server{handlers: h}).dispatch(body)
+}
+
+func (s *server) dispatch(body []byte) ([]byte, error) {
+	var batch []json.RawMessage
+	if err := json.Unmarshal(body, &batch); err != nil {
+		var req Request
+		if err := json.Unmarshal(body, &req); err != nil {
+			return nil, fmt.Errorf("error decoding request: %v", err)
+		}
+		return json.Marshal(s.answer(req))
+	}
+
+	if s.counter != nil {
+		s.counter.addBatch(len(batch))
+	}
+	if s.maxBatchSize > 0 && len(batch) > s.maxBatchSize {
+		return json.Marshal(response{Version: "2.0", ID: json.RawMessage("null"), Error: &Error{Code: -32600, Message: "batch size too large"}})
+	}
+	responses := make([]response, len(batch))
+	for i, raw := range batch {
+		var req Request
+		if err := json.Unmarshal(raw, &req); err != nil {
+			return nil, fmt.Errorf("error decoding request %d of batch: %v", i, err)
+		}
+		pos := i
+		if s.reversed {
+			pos = len(batch) - 1 - i
+		}
+		responses[pos] = s.answer(req)
+	}
+	return json.Marshal(responses)
+}
+
+func (s *server) answer(req Request) response {
+	if s.counter != nil {
+		s.counter.add(req.Method)
+	}
+	resp := response{Version: "2.0", ID: req.ID}
+	handler, ok := s.handlers[req.Method]
+	if !ok {
+		resp.Error = &Error{Code: -32601, Message: fmt.Sprintf("the method %s does not exist/is not available", req.Method)}
+		return resp
+	}
+
+	result, err := handler(req)
+	var rpcErr *Error
+	switch {
+	case errors.As(err, &rpcErr):
+		resp.Error = rpcErr
+	case err != nil:
+		resp.Error = &Error{Code: -32603, Message: err.Error()}
+	case result == nil:
+		resp.Result = json.RawMessage("null")
+	default:
+		resp.Result = result
+	}
+	return resp
+}
+
+// NewHandler returns an HTTP handler answering the JSON-RPC calls of request bodies with the handlers.
+// Malformed requests fail the test.
+func NewHandler(t testing.TB, handlers Handlers, opts ...Option) http.Handler {
+	s := &server{handlers: handlers}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("error reading request body: %v", err)
+			return
+		}
+		resp, err := s.dispatch(body)
+		if err != nil {
+			t.Errorf("error dispatching request: %v", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write(resp); err != nil {
+			t.Errorf("error writing response: %v", err)
+		}
+	})
+}
+
+// NewServer starts a server answering JSON-RPC calls with the handlers, closed when the test ends.
+func NewServer(t testing.TB, handlers Handlers, opts ...Option) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(NewHandler(t, handlers, opts...))
+	t.Cleanup(server.Close)
+	return server
+}
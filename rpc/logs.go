@@ -0,0 +1,247 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// DefaultMaxLogRange is the widest block range of a single eth_getLogs call made by FilterLogs, for clients created without WithMaxLogRange.
+const DefaultMaxLogRange = 1000
+
+// DefaultLogConcurrency is the number of eth_getLogs calls FilterLogs makes in parallel, for clients created without WithLogConcurrency.
+const DefaultLogConcurrency = 4
+
+// WithMaxLogRange sets the widest block range FilterLogs queries in a single eth_getLogs call, wider ranges are split.
+func WithMaxLogRange(blocks uint64) Option {
+	return func(c *Client) {
+		c.maxLogRange = blocks
+	}
+}
+
+// WithLogConcurrency sets how many eth_getLogs calls FilterLogs makes in parallel.
+func WithLogConcurrency(n int) Option {
+	return func(c *Client) {
+		c.logConcurrency = n
+	}
+}
+
+// Log is an event emitted by a contract.
+type Log struct {
+	Address Address `json:"address"`
+	// Topics holds the event signature hash followed by the indexed arguments
+	Topics []Hash `json:"topics"`
+	// Data holds the ABI encoded non indexed arguments
+	Data             Bytes    `json:"data"`
+	BlockNumber      Quantity `json:"blockNumber"`
+	BlockHash        Hash     `json:"blockHash"`
+	TransactionHash  Hash     `json:"transactionHash"`
+	TransactionIndex Quantity `json:"transactionIndex"`
+	LogIndex         Quantity `json:"logIndex"`
+	// Removed is set when the log was reverted by a chain reorganisation
+	Removed bool `json:"removed"`
+}
+
+// FilterQuery selects logs by block range, emitting contracts and topics.
+type FilterQuery struct {
+	// BlockHash restricts the query to a single block, it excludes FromBlock and ToBlock
+	BlockHash *Hash
+	// FromBlock and ToBlock bound the query, nil meaning the latest block
+	FromBlock *uint64
+	ToBlock   *uint64
+	// Addresses restricts the logs to the ones emitted by any of these contracts
+	Addresses []Address
+	// Topics restricts the logs by position, each position matching any of its hashes, an empty position matching anything
+	Topics [][]Hash
+}
+
+func (q FilterQuery) MarshalJSON() ([]byte, error) {
+	arg := map[string]interface{}{}
+	if len(q.Addresses) > 0 {
+		arg["address"] = q.Addresses
+	}
+	if len(q.Topics) > 0 {
+		topics := make([]interface{}, len(q.Topics))
+		for i, position := range q.Topics {
+			switch len(position) {
+			case 0:
+				topics[i] = nil
+			case 1:
+				topics[i] = position[0]
+			default:
+				topics[i] = position
+			}
+		}
+		arg["topics"] = topics
+	}
+	if q.BlockHash != nil {
+		arg["blockHash"] = *q.BlockHash
+		return json.Marshal(arg)
+	}
+	if q.FromBlock != nil {
+		arg["fromBlock"] = Quantity(*q.FromBlock)
+	}
+	if q.ToBlock != nil {
+		arg["toBlock"] = Quantity(*q.ToBlock)
+	}
+	return json.Marshal(arg)
+}
+
+// Logs returns the logs matching the filter query, in the order they were emitted.
+func (c *Client) Logs(ctx context.Context, q FilterQuery) ([]Log, error) {
+	return Send[[]Log](ctx, c, Request{Method: "eth_getLogs", Params: []interface{}{q}})
+}
+
+// FilterLogs sends the logs matching the filter query to ch, in block and log index order, and returns once they are all sent.
+// The block range is split into ranges of at most WithMaxLogRange blocks queried in parallel, and a range the endpoint
+// reports as returning too many results is bisected until it is accepted. A nil ToBlock is resolved to the latest block.
+// ch is not closed, the logs sent before an error are complete up to the failed range.
+func (c *Client) FilterLogs(ctx context.Context, q FilterQuery, ch chan<- Log) error {
+	if q.BlockHash != nil {
+		logs, err := c.Logs(ctx, q)
+		if err != nil {
+			return err
+		}
+		return sendLogs(ctx, ch, logs)
+	}
+
+	to := q.ToBlock
+	if to == nil {
+		latest, err := c.BlockNumber(ctx)
+		if err != nil {
+			return fmt.Errorf("error getting latest block: %w", err)
+		}
+		to = &latest
+	}
+	from := q.FromBlock
+	if from == nil {
+		from = to
+	}
+	if *from > *to {
+		return nil
+	}
+
+	size := c.maxLogRange
+	if size == 0 {
+		size = DefaultMaxLogRange
+	}
+	concurrency := c.logConcurrency
+	if concurrency <= 0 {
+		concurrency = DefaultLogConcurrency
+	}
+
+	// Queries are stopped and waited for on return, none outlives the call
+	var wg sync.WaitGroup
+	ctx, cancel := context.WithCancel(ctx)
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+
+	// Each range delivers its logs on a channel of its own, queued in block order, so that they are sent in order
+	// whatever order the queries complete in. The queue bounds how many queries run ahead of the sender.
+	pending := make(chan chan logsResult, concurrency-1)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(pending)
+		for start := *from; ; start += size {
+			end := *to
+			if *to-start >= size {
+				end = start + size - 1
+			}
+			result := make(chan logsResult, 1)
+			select {
+			case pending <- result:
+			case <-ctx.Done():
+				return
+			}
+			wg.Add(1)
+			go func(start, end uint64) {
+				defer wg.Done()
+				logs, err := c.logsInRange(ctx, q, start, end)
+				result <- logsResult{logs: logs, err: err}
+			}(start, end)
+			if end == *to {
+				return
+			}
+		}
+	}()
+
+	for result := range pending {
+		var res logsResult
+		select {
+		case res = <-result:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if res.err != nil {
+			return res.err
+		}
+		if err := sendLogs(ctx, ch, res.logs); err != nil {
+			return err
+		}
+	}
+	return ctx.Err()
+}
+
+type logsResult struct {
+	logs []Log
+	err  error
+}
+
+// logsInRange returns the logs of the blocks from..to, both included, bisecting the range while the endpoint rejects it.
+func (c *Client) logsInRange(ctx context.Context, q FilterQuery, from, to uint64) ([]Log, error) {
+	q.FromBlock, q.ToBlock = &from, &to
+	logs, err := c.Logs(ctx, q)
+	if err == nil {
+		return logs, nil
+	}
+	if from == to || !isLogRangeTooLarge(err) {
+		return nil, fmt.Errorf("error getting logs of blocks %d to %d: %w", from, to, err)
+	}
+
+	mid := from + (to-from)/2
+	left, err := c.logsInRange(ctx, q, from, mid)
+	if err != nil {
+		return nil, err
+	}
+	right, err := c.logsInRange(ctx, q, mid+1, to)
+	if err != nil {
+		return nil, err
+	}
+	return append(left, right...), nil
+}
+
+func sendLogs(ctx context.Context, ch chan<- Log, logs []Log) error {
+	for _, log := range logs {
+		select {
+		case ch <- log:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// isLogRangeTooLarge reports whether the endpoint rejected a log query because of its block range or number of results.
+// Providers do not agree on how to report it, so the error message is looked at.
+func isLogRangeTooLarge(err error) bool {
+	var rpcErr *RPCError
+	if !errors.As(err, &rpcErr) {
+		return false
+	}
+	message := strings.ToLower(rpcErr.Message)
+	switch {
+	case strings.Contains(message, "more than") && strings.Contains(message, "result"),
+		strings.Contains(message, "too many") && (strings.Contains(message, "result") || strings.Contains(message, "log")),
+		strings.Contains(message, "response size"):
+		return true
+	case strings.Contains(message, "range"):
+		return strings.Contains(message, "exceed") || strings.Contains(message, "too") || strings.Contains(message, "limit") || strings.Contains(message, "max")
+	}
+	return false
+}
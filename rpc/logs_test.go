@@ -0,0 +1,169 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rafaribe/polygon-client/rpc/internal/rpctest"
+)
+
+func TestLogs(t *testing.T) {
+	var params json.RawMessage
+	server := rpctest.NewServer(t, rpctest.Handlers{
+		"eth_getLogs": func(req rpctest.Request) (interface{}, error) {
+			encoded, err := json.Marshal(req.Params)
+			params = encoded
+			return json.RawMessage(`[{
+				"address": "0xc2132d05d31c914a87c6611c10748aeb04b58e8f",
+				"topics": ["0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef"],
+				"data": "0x00000000000000000000000000000000000000000000000000000000000f4240",
+				"blockNumber": "0x3a9f1c2",
+				"blockHash": "` + testBlockHash + `",
+				"transactionHash": "0x1111111111111111111111111111111111111111111111111111111111111111",
+				"transactionIndex": "0x0",
+				"logIndex": "0x4",
+				"removed": false
+			}]`), err
+		},
+	})
+
+	client, err := NewClient([]string{server.URL})
+	if err != nil {
+		t.Fatalf("NewClient returned unexpected error: %v", err)
+	}
+	token, _ := HexToAddress("0xc2132d05d31c914a87c6611c10748aeb04b58e8f")
+	transfer := mustHash(t, "0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef")
+	from, to := uint64(0x3a9f1c0), uint64(0x3a9f1c2)
+
+	logs, err := client.Logs(context.Background(), FilterQuery{
+		FromBlock: &from,
+		ToBlock:   &to,
+		Addresses: []Address{token},
+		Topics:    [][]Hash{{transfer}},
+	})
+	if err != nil {
+		t.Fatalf("Logs returned unexpected error: %v", err)
+	}
+
+	expected := `[{"address":["0xc2132d05d31c914a87c6611c10748aeb04b58e8f"],"fromBlock":"0x3a9f1c0","toBlock":"0x3a9f1c2","topics":["0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef"]}]`
+	if string(params) != expected {
+		t.Errorf("expected params %s, got %s", expected, params)
+	}
+	if len(logs) != 1 || logs[0].Address != token || logs[0].LogIndex != 4 || logs[0].Topics[0] != transfer {
+		t.Errorf("unexpected logs %+v", logs)
+	}
+}
+
+func TestFilterLogsSplitsRange(t *testing.T) {
+	var mu sync.Mutex
+	var ranges []string
+	var inFlight, maxInFlight int32
+	// eth_getLogs returns two logs per block, after a random delay so that queries complete out of order.
+	// Ranges wider than 8 blocks that include block 150 are rejected for returning too many results.
+	server := rpctest.NewServer(t, rpctest.Handlers{
+		"eth_getLogs": func(req rpctest.Request) (interface{}, error) {
+			n := atomic.AddInt32(&inFlight, 1)
+			defer atomic.AddInt32(&inFlight, -1)
+			for {
+				max := atomic.LoadInt32(&maxInFlight)
+				if n <= max || atomic.CompareAndSwapInt32(&maxInFlight, max, n) {
+					break
+				}
+			}
+			time.Sleep(time.Duration(rand.Intn(3)) * time.Millisecond)
+
+			var query struct {
+				FromBlock Quantity `json:"fromBlock"`
+				ToBlock   Quantity `json:"toBlock"`
+			}
+			if err := req.Param(0, &query); err != nil {
+				return nil, err
+			}
+			from, to := query.FromBlock.Uint64(), query.ToBlock.Uint64()
+			mu.Lock()
+			ranges = append(ranges, fmt.Sprintf("%d-%d", from, to))
+			mu.Unlock()
+
+			if to-from+1 > 8 && from <= 150 && 150 <= to {
+				return nil, &rpctest.Error{Code: CodeLimitExceeded, Message: "query returned more than 10000 results"}
+			}
+			var logs []string
+			for number := from; number <= to; number++ {
+				for index := 0; index < 2; index++ {
+					logs = append(logs, fmt.Sprintf(`{"blockNumber":"%s","logIndex":"0x%x","topics":[],"data":"0x"}`, Quantity(number), index))
+				}
+			}
+			return json.RawMessage("[" + strings.Join(logs, ",") + "]"), nil
+		},
+	})
+	client, err := NewClient([]string{server.URL}, WithMaxLogRange(50), WithLogConcurrency(3))
+	if err != nil {
+		t.Fatalf("NewClient returned unexpected error: %v", err)
+	}
+
+	from, to := uint64(100), uint64(299)
+	ch := make(chan Log)
+	errc := make(chan error, 1)
+	go func() {
+		errc <- client.FilterLogs(context.Background(), FilterQuery{FromBlock: &from, ToBlock: &to}, ch)
+		close(ch)
+	}()
+	var logs []Log
+	for log := range ch {
+		logs = append(logs, log)
+	}
+	if err := <-errc; err != nil {
+		t.Fatalf("FilterLogs returned unexpected error: %v", err)
+	}
+
+	if len(logs) != 400 {
+		t.Fatalf("expected 400 logs, got %d", len(logs))
+	}
+	for i, log := range logs {
+		if log.BlockNumber.Uint64() != from+uint64(i/2) || log.LogIndex.Uint64() != uint64(i%2) {
+			t.Fatalf("expected log %d of block %d at position %d, got log %d of block %d", i%2, from+uint64(i/2), i, log.LogIndex, log.BlockNumber)
+		}
+	}
+
+	// The range around the dense block is bisected, 150-199 into 150-174, 150-162, 150-156 and their siblings, without retries
+	seen := make(map[string]int)
+	for _, r := range ranges {
+		seen[r]++
+	}
+	for _, expected := range []string{"100-149", "150-199", "150-174", "150-162", "150-156", "157-162", "163-174", "175-199", "200-249", "250-299"} {
+		if seen[expected] != 1 {
+			t.Errorf("expected range %s to be queried once, got %d times", expected, seen[expected])
+		}
+	}
+	if maxInFlight > 3 {
+		t.Errorf("expected at most 3 queries in flight, got %d", maxInFlight)
+	}
+}
+
+func TestIsLogRangeTooLarge(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected bool
+	}{
+		{&RPCError{Code: -32005, Message: "query returned more than 10000 results"}, true},
+		{&RPCError{Code: -32602, Message: "Log response size exceeded. You can make eth_getLogs requests with up to a 2K block range"}, true},
+		{&RPCError{Code: -32000, Message: "block range is too wide"}, true},
+		{&RPCError{Code: -32000, Message: "exceed maximum block range: 1000"}, true},
+		{&RPCError{Code: -32005, Message: "daily request count exceeded, request rate limited"}, false},
+		{&RPCError{Code: -32000, Message: "header not found"}, false},
+		{&HTTPStatusError{StatusCode: 413}, false},
+	}
+
+	for _, tt := range tests {
+		if got := isLogRangeTooLarge(tt.err); got != tt.expected {
+			t.Errorf("expected isLogRangeTooLarge(%v) to be %t, got %t", tt.err, tt.expected, got)
+		}
+	}
+}
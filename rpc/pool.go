@@ -0,0 +1,263 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// latencyAlpha is the weight given to the newest sample in the latency moving average.
+const latencyAlpha = 0.3
+
+// endpoint tracks the health of a single RPC endpoint of the pool.
+type endpoint struct {
+	url string
+	// name identifies the endpoint in logs, metrics and traces, see endpointNames
+	name string
+	// consecutiveFailures is reset on every successful request
+	consecutiveFailures int
+	// latency is the exponentially weighted moving average of successful request durations
+	latency time.Duration
+	// lastBlock is the highest block number this endpoint reported
+	lastBlock uint64
+	// unhealthyUntil is set once the endpoint reaches the failure threshold, it is re-admitted after that time
+	unhealthyUntil time.Time
+}
+
+// RedactURL returns the scheme and host of an endpoint URL, leaving out the user info, path and query where
+// providers put API keys, e.g. https://polygon-mainnet.g.alchemy.com for https://polygon-mainnet.g.alchemy.com/v2/<key>.
+func RedactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return "invalid URL"
+	}
+	return u.Scheme + "://" + u.Host
+}
+
+// endpointNames names the endpoints after their redacted URL.
+// Endpoints sharing a host, e.g. with a key each, are numbered in the order they were given so that names stay unique.
+func endpointNames(urls []string) []string {
+	names := make([]string, len(urls))
+	hosts := map[string]int{}
+	for i, raw := range urls {
+		names[i] = RedactURL(raw)
+		hosts[names[i]]++
+	}
+	numbers := map[string]int{}
+	for i, name := range names {
+		if hosts[name] > 1 {
+			numbers[name]++
+			names[i] = fmt.Sprintf("%s#%d", name, numbers[name])
+		}
+	}
+	return names
+}
+
+// endpointPool routes requests to the best healthy endpoint and fails over to the next one on errors.
+type endpointPool struct {
+	mu        sync.Mutex
+	endpoints []*endpoint
+	// maxFailures is the number of consecutive failures after which an endpoint is taken out of rotation
+	maxFailures int
+	// cooldown is how long an unhealthy endpoint stays out of rotation
+	cooldown time.Duration
+	// maxBlockLag is how many blocks an endpoint may be behind the highest known block before it is deprioritized
+	maxBlockLag uint64
+	// observer, when set, is notified of every request sent to an endpoint
+	observer Observer
+	// tracer creates a span for every request sent to an endpoint
+	tracer trace.Tracer
+	now    func() time.Time
+}
+
+func newEndpointPool(urls []string, maxFailures int, cooldown time.Duration) *endpointPool {
+	names := endpointNames(urls)
+	endpoints := make([]*endpoint, len(urls))
+	for i, url := range urls {
+		endpoints[i] = &endpoint{url: url, name: names[i]}
+	}
+	return &endpointPool{
+		endpoints:   endpoints,
+		maxFailures: maxFailures,
+		cooldown:    cooldown,
+		maxBlockLag: 5,
+		tracer:      defaultTracer(),
+		now:         time.Now,
+	}
+}
+
+// request sends the JSON-RPC request to the best endpoint, failing over to the others on transport or JSON-RPC errors.
+// Reverted executions and requests rejected as malformed are returned as is, they do not depend on the endpoint.
+// check, when set, validates the response body, an invalid response fails over like any other error.
+// It returns the response body along with the URL of the endpoint that served it.
+func (p *endpointPool) request(ctx context.Context, client *http.Client, reqBody interface{}, check func(body []byte) error) ([]byte, string, error) {
+	method := methodOf(reqBody)
+	var errs []error
+	for _, e := range p.candidates() {
+		start := p.now()
+		spanCtx, span := startSpan(ctx, p.tracer, method, e.name, reqBody)
+		resp, err := makeRPCRequest(spanCtx, client, e.url, reqBody)
+		if err == nil && check != nil {
+			err = check(resp)
+		}
+		endSpan(span, resp, err)
+		if err != nil && ctx.Err() != nil {
+			// The caller gave up, this says nothing about the health of the endpoint
+			return nil, "", ctx.Err()
+		}
+		latency := p.now().Sub(start)
+		if p.observer != nil {
+			p.observer.ObserveRequest(method, e.name, latency, err)
+		}
+		if err != nil {
+			slog.Debug("request failed", "method", method, "endpoint", e.name, "latency_ms", latency.Milliseconds(), ErrorAttr(err))
+		} else {
+			slog.Debug("request succeeded", "method", method, "endpoint", e.name, "latency_ms", latency.Milliseconds())
+		}
+		if err != nil && (isBatchTooLarge(err) || isLogRangeTooLarge(err)) {
+			// The batch or the log query has to be split, which the caller takes care of
+			return nil, e.url, err
+		}
+		if err != nil && (errors.Is(err, ErrExecutionReverted) || isRequestError(err)) {
+			// The endpoint executed the call or rejected the request itself, every other one would answer the same way
+			p.recordSuccess(e, latency)
+			return nil, e.url, err
+		}
+		if err != nil {
+			p.recordFailure(e, err)
+			errs = append(errs, fmt.Errorf("%s: %w", e.name, err))
+			continue
+		}
+		p.recordSuccess(e, latency)
+		return resp, e.url, nil
+	}
+	return nil, "", fmt.Errorf("all endpoints failed: %w", errors.Join(errs...))
+}
+
+// isRequestError reports whether the endpoint rejected the request itself, as malformed, with unknown method or invalid params.
+func isRequestError(err error) bool {
+	var rpcErr *RPCError
+	if !errors.As(err, &rpcErr) {
+		return false
+	}
+	switch rpcErr.Code {
+	case CodeParseError, CodeInvalidRequest, CodeMethodNotFound, CodeInvalidParams:
+		return true
+	}
+	return false
+}
+
+// methodOf names the method of a request, batches are named batch.
+func methodOf(reqBody interface{}) string {
+	switch req := reqBody.(type) {
+	case wireRequest:
+		return req.Method
+	case []wireRequest:
+		return "batch"
+	}
+	return "unknown"
+}
+
+// status returns a snapshot of the health of every endpoint.
+func (p *endpointPool) status() []EndpointStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+	statuses := make([]EndpointStatus, len(p.endpoints))
+	for i, e := range p.endpoints {
+		statuses[i] = EndpointStatus{
+			URL:                 e.url,
+			Name:                e.name,
+			Healthy:             !now.Before(e.unhealthyUntil),
+			ConsecutiveFailures: e.consecutiveFailures,
+			Latency:             e.latency,
+			LastBlock:           e.lastBlock,
+		}
+	}
+	return statuses
+}
+
+// observeBlock records the latest block number reported by an endpoint.
+func (p *endpointPool) observeBlock(url string, number uint64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, e := range p.endpoints {
+		if e.url == url && number > e.lastBlock {
+			e.lastBlock = number
+		}
+	}
+}
+
+// candidates returns the endpoints in the order they should be tried.
+// Healthy endpoints come first, those keeping up with the chain head ahead of lagging ones, then by lowest latency.
+// Unhealthy endpoints are kept as a last resort, the ones closest to re-admission first.
+func (p *endpointPool) candidates() []*endpoint {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+	var head uint64
+	for _, e := range p.endpoints {
+		if e.lastBlock > head {
+			head = e.lastBlock
+		}
+	}
+
+	var healthy, unhealthy []*endpoint
+	for _, e := range p.endpoints {
+		if now.Before(e.unhealthyUntil) {
+			unhealthy = append(unhealthy, e)
+		} else {
+			healthy = append(healthy, e)
+		}
+	}
+
+	lagging := func(e *endpoint) bool { return head-e.lastBlock > p.maxBlockLag }
+	sort.SliceStable(healthy, func(i, j int) bool {
+		if li, lj := lagging(healthy[i]), lagging(healthy[j]); li != lj {
+			return lj
+		}
+		return healthy[i].latency < healthy[j].latency
+	})
+	sort.SliceStable(unhealthy, func(i, j int) bool {
+		return unhealthy[i].unhealthyUntil.Before(unhealthy[j].unhealthyUntil)
+	})
+	return append(healthy, unhealthy...)
+}
+
+func (p *endpointPool) recordSuccess(e *endpoint, latency time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !e.unhealthyUntil.IsZero() {
+		slog.Info("endpoint healthy again", "endpoint", e.name)
+	}
+	e.consecutiveFailures = 0
+	e.unhealthyUntil = time.Time{}
+	if e.latency == 0 {
+		e.latency = latency
+	} else {
+		e.latency = time.Duration(latencyAlpha*float64(latency) + (1-latencyAlpha)*float64(e.latency))
+	}
+}
+
+func (p *endpointPool) recordFailure(e *endpoint, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	e.consecutiveFailures++
+	if e.consecutiveFailures >= p.maxFailures {
+		e.unhealthyUntil = p.now().Add(p.cooldown)
+		slog.Warn("endpoint marked unhealthy", "endpoint", e.name, "cooldown_ms", p.cooldown.Milliseconds(), "consecutive_failures", e.consecutiveFailures, ErrorAttr(err))
+	}
+}
@@ -0,0 +1,110 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Receipt statuses, see EIP-658.
+const (
+	ReceiptStatusFailed     = 0
+	ReceiptStatusSuccessful = 1
+)
+
+// Receipt is the outcome of a transaction included in a block.
+type Receipt struct {
+	Type             Quantity `json:"type"`
+	TransactionHash  Hash     `json:"transactionHash"`
+	TransactionIndex Quantity `json:"transactionIndex"`
+	BlockHash        Hash     `json:"blockHash"`
+	BlockNumber      Quantity `json:"blockNumber"`
+	From             Address  `json:"from"`
+	// To is nil for contract creations
+	To *Address `json:"to"`
+	// ContractAddress is the address of the created contract, nil for other transactions
+	ContractAddress *Address `json:"contractAddress"`
+	// CumulativeGasUsed is the gas used by this transaction and the ones before it in the block
+	CumulativeGasUsed Quantity `json:"cumulativeGasUsed"`
+	GasUsed           Quantity `json:"gasUsed"`
+	// EffectiveGasPrice is the price per gas actually paid
+	EffectiveGasPrice *BigInt `json:"effectiveGasPrice"`
+	// Status is ReceiptStatusSuccessful or ReceiptStatusFailed
+	Status    Quantity `json:"status"`
+	Logs      []Log    `json:"logs"`
+	LogsBloom Bytes    `json:"logsBloom"`
+
+	// BlobGasUsed and BlobGasPrice are set for blob transactions
+	BlobGasUsed  *Quantity `json:"blobGasUsed,omitempty"`
+	BlobGasPrice *BigInt   `json:"blobGasPrice,omitempty"`
+}
+
+// Succeeded reports whether the transaction executed without reverting.
+func (r *Receipt) Succeeded() bool {
+	return r.Status == ReceiptStatusSuccessful
+}
+
+// TransactionReceipt returns the receipt of the transaction with the given hash.
+// It returns ErrReceiptNotFound when the transaction is unknown or not included in a block yet.
+func (c *Client) TransactionReceipt(ctx context.Context, hash Hash) (*Receipt, error) {
+	receipt, err := Send[*Receipt](ctx, c, Request{Method: "eth_getTransactionReceipt", Params: []interface{}{hash}})
+	if err != nil {
+		return nil, err
+	}
+	if receipt == nil {
+		return nil, ErrReceiptNotFound
+	}
+	return receipt, nil
+}
+
+// BlockReceipts returns the receipts of every transaction of the block with the given number, in transaction order.
+// It uses eth_getBlockReceipts and, once an endpoint reports it does not support it, fetches the receipts one by one in batches.
+// It returns ErrBlockNotFound when the endpoint does not know the block yet.
+func (c *Client) BlockReceipts(ctx context.Context, number uint64) ([]*Receipt, error) {
+	if !c.noBlockReceipts.Load() {
+		receipts, err := Send[[]*Receipt](ctx, c, Request{Method: "eth_getBlockReceipts", Params: []interface{}{Quantity(number)}})
+		if !errors.Is(err, ErrMethodNotFound) {
+			if err == nil && receipts == nil {
+				return nil, ErrBlockNotFound
+			}
+			return receipts, err
+		}
+		c.noBlockReceipts.Store(true)
+	}
+	return c.blockReceiptsByTransaction(ctx, number)
+}
+
+// blockReceiptsByTransaction fetches the receipts of the block's transactions with one eth_getTransactionReceipt call each.
+func (c *Client) blockReceiptsByTransaction(ctx context.Context, number uint64) ([]*Receipt, error) {
+	block, err := c.BlockByNumber(ctx, number, false)
+	if err != nil {
+		return nil, err
+	}
+
+	hashes := block.Transactions.Hashes()
+	receipts := make([]*Receipt, len(hashes))
+	elems := make([]BatchElem, len(hashes))
+	for i, hash := range hashes {
+		elems[i] = BatchElem{
+			Method: "eth_getTransactionReceipt",
+			Params: []interface{}{hash},
+			Result: &receipts[i],
+		}
+	}
+	if err := c.BatchDo(ctx, elems); err != nil {
+		return nil, err
+	}
+	for i, elem := range elems {
+		if elem.Error != nil {
+			return nil, fmt.Errorf("error getting receipt of transaction %s: %w", hashes[i], elem.Error)
+		}
+		if receipts[i] == nil {
+			return nil, fmt.Errorf("error getting receipt of transaction %s: %w", hashes[i], ErrReceiptNotFound)
+		}
+		// A reorganisation between the two requests would mix receipts of different blocks
+		if receipts[i].BlockHash != block.Hash {
+			return nil, fmt.Errorf("error getting receipt of transaction %s: block %d changed from %s to %s while fetching its receipts", hashes[i], number, block.Hash, receipts[i].BlockHash)
+		}
+	}
+	return receipts, nil
+}
@@ -0,0 +1,176 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/rafaribe/polygon-client/rpc/internal/rpctest"
+)
+
+const testBlockHash = "0x9b74b2c9bbc6d7d95c2e9fbd4b1d6cfd50c7d1b4ad1ea1e0f84e2a3cbaef1c53"
+
+// testReceipt is a successful ERC-20 transfer receipt of the test block, with the given transaction hash and index.
+func testReceipt(txHash string, index int) string {
+	return fmt.Sprintf(`{
+		"type": "0x2",
+		"transactionHash": %q,
+		"transactionIndex": "0x%x",
+		"blockHash": %q,
+		"blockNumber": "0x3a9f1c2",
+		"from": "0x6e1f5c1c8b7c1bb0b2b1f9b9b0d7e6f1c2a3b4c5",
+		"to": "0xc2132d05d31c914a87c6611c10748aeb04b58e8f",
+		"contractAddress": null,
+		"cumulativeGasUsed": "0x1f6a3",
+		"gasUsed": "0xb4a3",
+		"effectiveGasPrice": "0x7558bdb000",
+		"status": "0x1",
+		"logsBloom": "0x00",
+		"logs": [{
+			"address": "0xc2132d05d31c914a87c6611c10748aeb04b58e8f",
+			"topics": [
+				"0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef",
+				"0x0000000000000000000000006e1f5c1c8b7c1bb0b2b1f9b9b0d7e6f1c2a3b4c5",
+				"0x000000000000000000000000a0b86991c6218b36c1d19d4a2e9eb0ce3606eb48"
+			],
+			"data": "0x00000000000000000000000000000000000000000000000000000000000f4240",
+			"blockNumber": "0x3a9f1c2",
+			"blockHash": %q,
+			"transactionHash": %q,
+			"transactionIndex": "0x%x",
+			"logIndex": "0x%x",
+			"removed": false
+		}]
+	}`, txHash, index, testBlockHash, testBlockHash, txHash, index, index)
+}
+
+func TestTransactionReceipt(t *testing.T) {
+	txHash := "0x5a0e2f0b4f3b7bd2c7b1f7f0c9f87a3d8f1a2b3c4d5e6f708192a3b4c5d6e7f8"
+	server := rpctest.NewServer(t, rpctest.Handlers{
+		"eth_getTransactionReceipt": rpctest.Result(testReceipt(txHash, 3)),
+	})
+	client, err := NewClient([]string{server.URL})
+	if err != nil {
+		t.Fatalf("NewClient returned unexpected error: %v", err)
+	}
+
+	receipt, err := client.TransactionReceipt(context.Background(), mustHash(t, txHash))
+	if err != nil {
+		t.Fatalf("TransactionReceipt returned unexpected error: %v", err)
+	}
+	if !receipt.Succeeded() || receipt.TransactionIndex != 3 || receipt.GasUsed != 0xb4a3 {
+		t.Errorf("unexpected receipt %+v", receipt)
+	}
+	if receipt.ContractAddress != nil {
+		t.Errorf("expected no contract address, got %s", receipt.ContractAddress)
+	}
+	if receipt.EffectiveGasPrice.ToInt().Int64() != 0x7558bdb000 {
+		t.Errorf("expected effective gas price %d, got %s", 0x7558bdb000, receipt.EffectiveGasPrice)
+	}
+	if len(receipt.Logs) != 1 || len(receipt.Logs[0].Topics) != 3 || receipt.Logs[0].LogIndex != 3 {
+		t.Errorf("unexpected logs %+v", receipt.Logs)
+	}
+}
+
+func TestTransactionReceiptNotFound(t *testing.T) {
+	server := rpctest.NewServer(t, rpctest.Handlers{
+		"eth_getTransactionReceipt": rpctest.Result(`null`),
+	})
+	client, err := NewClient([]string{server.URL})
+	if err != nil {
+		t.Fatalf("NewClient returned unexpected error: %v", err)
+	}
+
+	if _, err := client.TransactionReceipt(context.Background(), Hash{}); !errors.Is(err, ErrReceiptNotFound) {
+		t.Errorf("expected ErrReceiptNotFound, got %v", err)
+	}
+}
+
+func TestBlockReceipts(t *testing.T) {
+	txHashes := []string{
+		"0x1111111111111111111111111111111111111111111111111111111111111111",
+		"0x2222222222222222222222222222222222222222222222222222222222222222",
+	}
+	// The block has two transactions, whose receipts are fetched one by one when eth_getBlockReceipts is not supported
+	handlers := rpctest.Handlers{
+		"eth_getBlockByNumber": rpctest.Result(fmt.Sprintf(`{"number":"0x3a9f1c2","hash":%q,"timestamp":"0x0","transactions":[%q,%q]}`, testBlockHash, txHashes[0], txHashes[1])),
+		"eth_getTransactionReceipt": func(req rpctest.Request) (interface{}, error) {
+			var hash string
+			if err := req.Param(0, &hash); err != nil {
+				return nil, err
+			}
+			for i, txHash := range txHashes {
+				if txHash == hash {
+					return json.RawMessage(testReceipt(hash, i)), nil
+				}
+			}
+			return nil, nil
+		},
+	}
+
+	tests := []struct {
+		name          string
+		blockReceipts rpctest.Handler
+		expected      map[string]int
+	}{
+		{
+			name:          "eth_getBlockReceipts supported",
+			blockReceipts: rpctest.Result(fmt.Sprintf(`[%s,%s]`, testReceipt(txHashes[0], 0), testReceipt(txHashes[1], 1))),
+			expected:      map[string]int{"eth_getBlockReceipts": 2},
+		},
+		{
+			name:          "eth_getBlockReceipts not supported",
+			blockReceipts: rpctest.Fail(CodeMethodNotFound, "the method eth_getBlockReceipts does not exist/is not available"),
+			expected:      map[string]int{"eth_getBlockReceipts": 1, "eth_getBlockByNumber": 2, "eth_getTransactionReceipt": 4},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handlers["eth_getBlockReceipts"] = tt.blockReceipts
+			var counter rpctest.Counter
+			first := rpctest.NewServer(t, handlers, rpctest.WithCounter(&counter))
+			second := rpctest.NewServer(t, handlers, rpctest.WithCounter(&counter))
+			// A single failure is enough to take an endpoint out of rotation
+			client, err := NewClient([]string{first.URL, second.URL}, WithEndpointHealth(1, time.Minute))
+			if err != nil {
+				t.Fatalf("NewClient returned unexpected error: %v", err)
+			}
+
+			// The second call shows whether the client remembers eth_getBlockReceipts is unsupported
+			for i := 0; i < 2; i++ {
+				receipts, err := client.BlockReceipts(context.Background(), 0x3a9f1c2)
+				if err != nil {
+					t.Fatalf("BlockReceipts returned unexpected error: %v", err)
+				}
+				if len(receipts) != 2 || receipts[0].TransactionIndex != 0 || receipts[1].TransactionIndex != 1 {
+					t.Fatalf("expected the 2 receipts of the block in order, got %+v", receipts)
+				}
+			}
+
+			for method, n := range tt.expected {
+				if calls := counter.Calls(method); calls != n {
+					t.Errorf("expected %d calls to %s, got %d", n, method, calls)
+				}
+			}
+			// Probing eth_getBlockReceipts says nothing about the health of the endpoints
+			for _, status := range client.Endpoints() {
+				if !status.Healthy || status.ConsecutiveFailures != 0 {
+					t.Errorf("expected endpoint %s to stay healthy, got %+v", status.Name, status)
+				}
+			}
+		})
+	}
+}
+
+func mustHash(t *testing.T, s string) Hash {
+	t.Helper()
+	h, err := HexToHash(s)
+	if err != nil {
+		t.Fatalf("invalid hash %s: %v", s, err)
+	}
+	return h
+}
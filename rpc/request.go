@@ -0,0 +1,59 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// Request is a JSON-RPC call, the client fills in the jsonrpc version and the id when sending it.
+type Request struct {
+	Method string
+	Params []interface{}
+}
+
+// Response is the envelope of a JSON-RPC response whose result decodes into T.
+type Response[T any] struct {
+	Version string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  T               `json:"result"`
+	Error   *RPCError       `json:"error,omitempty"`
+}
+
+// wireRequest is a Request as sent to the endpoint.
+type wireRequest struct {
+	Version string        `json:"jsonrpc"`
+	ID      uint64        `json:"id"`
+	Method  string        `json:"method"`
+	Params  []interface{} `json:"params,omitempty"`
+}
+
+func newWireRequest(id uint64, req Request) wireRequest {
+	return wireRequest{Version: "2.0", ID: id, Method: req.Method, Params: req.Params}
+}
+
+// Send sends the request and decodes its result into T.
+func Send[T any](ctx context.Context, c *Client, req Request) (T, error) {
+	result, _, err := send[T](ctx, c, req)
+	return result, err
+}
+
+// send is Send also returning the URL of the endpoint that served the request.
+func send[T any](ctx context.Context, c *Client, req Request) (T, string, error) {
+	wire := newWireRequest(c.nextIDs(1), req)
+
+	var resp Response[T]
+	var endpoint string
+	err := c.retry.do(ctx, func() error {
+		var err error
+		_, endpoint, err = c.pool.request(ctx, c.httpClient, wire, func(body []byte) error {
+			resp = Response[T]{}
+			if err := json.Unmarshal(body, &resp); err != nil {
+				return &DecodeError{Method: req.Method, Err: err}
+			}
+			// A mismatched response may belong to another request, the next endpoint is tried
+			return checkResponse(req.Method, resp.Version, resp.ID, wire.ID)
+		})
+		return err
+	})
+	return resp.Result, endpoint, err
+}
@@ -0,0 +1,39 @@
+package rpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rafaribe/polygon-client/rpc/internal/rpctest"
+)
+
+func TestSend(t *testing.T) {
+	var received rpctest.Request
+	server := rpctest.NewServer(t, rpctest.Handlers{
+		"eth_chainIds": func(req rpctest.Request) (interface{}, error) {
+			received = req
+			return []string{"0x89", "0x13881"}, nil
+		},
+	})
+
+	client, err := NewClient([]string{server.URL})
+	if err != nil {
+		t.Fatalf("NewClient returned unexpected error: %v", err)
+	}
+
+	result, err := Send[[]Quantity](context.Background(), client, Request{Method: "eth_chainIds"})
+	if err != nil {
+		t.Fatalf("Send returned unexpected error: %v", err)
+	}
+	if len(result) != 2 || result[0] != 137 || result[1] != 80001 {
+		t.Errorf("expected [137 80001], got %v", result)
+	}
+
+	// The client fills in the envelope, params are left out when there are none
+	if received.Version != "2.0" || string(received.ID) != "1" || received.Method != "eth_chainIds" {
+		t.Errorf("unexpected request envelope %+v", received)
+	}
+	if received.Params != nil {
+		t.Errorf("expected no params, got %s", received.Params)
+	}
+}
@@ -0,0 +1,135 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy retries failed requests with exponential backoff and full jitter.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first one
+	MaxAttempts int
+	// BaseDelay is the backoff ceiling of the first retry, it doubles on every attempt
+	BaseDelay time.Duration
+	// MaxDelay caps the backoff ceiling
+	MaxDelay time.Duration
+
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// DefaultRetryPolicy is used by clients created without WithRetryPolicy.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	BaseDelay:   time.Millisecond * 250,
+	MaxDelay:    time.Second * 5,
+}
+
+// Backoff returns a random delay between zero and the exponential ceiling of the given attempt, starting at 1.
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	ceiling := p.BaseDelay
+	for i := 1; i < attempt && ceiling < p.MaxDelay; i++ {
+		ceiling *= 2
+	}
+	if ceiling > p.MaxDelay {
+		ceiling = p.MaxDelay
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(ceiling) + 1))
+}
+
+// do calls fn until it succeeds, returns a non retryable error, runs out of attempts or ctx is done.
+// A Retry-After header sent along with a rate limit response takes precedence over the computed backoff,
+// up to MaxDelay so that an endpoint asking for a long pause does not hold the request for as long.
+func (p RetryPolicy) do(ctx context.Context, fn func() error) error {
+	sleep := p.sleep
+	if sleep == nil {
+		sleep = sleepContext
+	}
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		if err = fn(); err == nil {
+			return nil
+		}
+		if attempt >= p.MaxAttempts || ctx.Err() != nil || !isRetryable(err) {
+			return err
+		}
+
+		delay := p.Backoff(attempt)
+		var statusErr *HTTPStatusError
+		if errors.As(err, &statusErr) && statusErr.RetryAfter > 0 {
+			delay = statusErr.RetryAfter
+			if p.MaxDelay > 0 && delay > p.MaxDelay {
+				delay = p.MaxDelay
+			}
+		}
+		slog.Warn("request attempt failed, retrying", "attempt", attempt, "max_attempts", p.MaxAttempts, "retry_in_ms", delay.Milliseconds(), ErrorAttr(err))
+		if sleepErr := sleep(ctx, delay); sleepErr != nil {
+			return err
+		}
+	}
+}
+
+// sleepContext waits for d, returning early with the context error when ctx is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// isRetryable reports whether a failed request may succeed if sent again.
+// Malformed requests, reverted executions and oversized batches will fail the same way every time.
+func isRetryable(err error) bool {
+	if isBatchTooLarge(err) || isLogRangeTooLarge(err) {
+		return false
+	}
+
+	if isRequestError(err) {
+		return false
+	}
+	var rpcErr *RPCError
+	if errors.As(err, &rpcErr) {
+		return rpcErr.Code != CodeExecutionReverted && !errors.Is(rpcErr, ErrExecutionReverted)
+	}
+
+	var statusErr *HTTPStatusError
+	if errors.As(err, &statusErr) {
+		switch {
+		case statusErr.StatusCode == http.StatusTooManyRequests, statusErr.StatusCode == http.StatusRequestTimeout:
+			return true
+		case statusErr.StatusCode >= 400 && statusErr.StatusCode < 500:
+			return false
+		}
+	}
+	return true
+}
+
+// parseRetryAfter parses a Retry-After header, given either in seconds or as an HTTP date.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil && date.After(now) {
+		return date.Sub(now)
+	}
+	return 0
+}
@@ -0,0 +1,123 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRetryBackoffBounds(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+
+	ceilings := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second, time.Second}
+	for i, ceiling := range ceilings {
+		for n := 0; n < 100; n++ {
+			if delay := policy.Backoff(i + 1); delay < 0 || delay > ceiling {
+				t.Fatalf("attempt %d: expected delay within [0, %s], got %s", i+1, ceiling, delay)
+			}
+		}
+	}
+}
+
+func TestRetryStopsOnNonRetryableError(t *testing.T) {
+	var sleeps []time.Duration
+	policy := RetryPolicy{MaxAttempts: 5, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond, sleep: func(_ context.Context, d time.Duration) error { sleeps = append(sleeps, d); return nil }}
+
+	calls := 0
+	err := policy.do(context.Background(), func() error {
+		calls++
+		return &RPCError{Code: CodeMethodNotFound, Message: "method not found"}
+	})
+	if !errors.Is(err, ErrMethodNotFound) {
+		t.Fatalf("expected method not found error, got %v", err)
+	}
+	if calls != 1 || len(sleeps) != 0 {
+		t.Errorf("expected a single attempt without sleeping, got %d attempts and %d sleeps", calls, len(sleeps))
+	}
+}
+
+func TestRetryHonorsRetryAfter(t *testing.T) {
+	tests := []struct {
+		retryAfter string
+		expected   time.Duration
+	}{
+		{retryAfter: "3", expected: 3 * time.Second},
+		// Waiting for an hour would outlast any caller, the pause is capped by MaxDelay
+		{retryAfter: "3600", expected: 5 * time.Second},
+	}
+
+	for _, tt := range tests {
+		calls := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			if calls == 1 {
+				w.Header().Set("Retry-After", tt.retryAfter)
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+			if _, err := w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":"0x1"}`)); err != nil {
+				t.Errorf("error writing response: %v", err)
+			}
+		}))
+
+		var sleeps []time.Duration
+		policy := RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Second, sleep: func(_ context.Context, d time.Duration) error { sleeps = append(sleeps, d); return nil }}
+		reqBody := map[string]interface{}{
+			"jsonrpc": "2.0",
+			"method":  "eth_blockNumber",
+			"id":      1,
+		}
+
+		err := policy.do(context.Background(), func() error {
+			_, err := makeRPCRequest(context.Background(), server.Client(), server.URL, reqBody)
+			return err
+		})
+		server.Close()
+		if err != nil {
+			t.Fatalf("Retry-After %s: expected request to succeed after a retry, got %v", tt.retryAfter, err)
+		}
+		if len(sleeps) != 1 || sleeps[0] != tt.expected {
+			t.Errorf("Retry-After %s: expected a single %s sleep, got %v", tt.retryAfter, tt.expected, sleeps)
+		}
+	}
+}
+
+func TestRetryGivesUpAfterMaxAttempts(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 3, sleep: func(context.Context, time.Duration) error { return nil }}
+
+	calls := 0
+	err := policy.do(context.Background(), func() error {
+		calls++
+		return &HTTPStatusError{StatusCode: http.StatusServiceUnavailable, Status: "503 Service Unavailable"}
+	})
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+	if calls != 3 {
+		t.Errorf("expected 3 attempts, got %d", calls)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value    string
+		expected time.Duration
+	}{
+		{value: "", expected: 0},
+		{value: "10", expected: 10 * time.Second},
+		{value: "-1", expected: 0},
+		{value: "Thu, 01 Jun 2023 12:00:30 GMT", expected: 30 * time.Second},
+		{value: "soon", expected: 0},
+	}
+
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.value, now); got != tt.expected {
+			t.Errorf("parseRetryAfter(%q): expected %s, got %s", tt.value, tt.expected, got)
+		}
+	}
+}
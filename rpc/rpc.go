@@ -0,0 +1,101 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"strconv"
+	"time"
+)
+
+// makeRPCRequest posts a JSON-RPC request, or a batch of requests, and returns the raw response body.
+func makeRPCRequest(ctx context.Context, client *http.Client, url string, reqBody interface{}) ([]byte, error) {
+	reqJSON, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling JSON request: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating HTTP request: %v", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Body = http.MaxBytesReader(nil, req.Body, 1048576)
+	req.Body = nopCloser{bytes.NewReader(reqJSON)}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		var urlErr *neturl.Error
+		if errors.As(err, &urlErr) {
+			// The error reads like Post "<url>": ..., the URL may hold an API key
+			urlErr.URL = RedactURL(urlErr.URL)
+		}
+		return nil, fmt.Errorf("error making HTTP request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading HTTP response body: %v", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, &HTTPStatusError{
+			StatusCode: resp.StatusCode,
+			Status:     resp.Status,
+			Body:       truncate(string(respBody), maxErrorBodyLength),
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+		}
+	}
+
+	// Batch responses carry their errors per item, they are left to the caller
+	if trimmed := bytes.TrimLeft(respBody, " \t\r\n"); len(trimmed) > 0 && trimmed[0] == '[' {
+		return respBody, nil
+	}
+
+	// Surface JSON-RPC error objects as Go errors so callers only ever decode successful results
+	var envelope struct {
+		Error *RPCError `json:"error"`
+	}
+	if err := json.Unmarshal(respBody, &envelope); err != nil {
+		return nil, &DecodeError{Method: "JSON-RPC", Err: err}
+	}
+	if envelope.Error != nil {
+		return nil, envelope.Error
+	}
+
+	return respBody, nil
+}
+
+// checkResponse verifies that a response declares JSON-RPC 2.0 and echoes the id of its request.
+func checkResponse(method, version string, id json.RawMessage, want uint64) error {
+	if version != "2.0" {
+		return &ProtocolError{Method: method, Reason: fmt.Sprintf("unexpected jsonrpc version %q", version)}
+	}
+	if got, err := strconv.ParseUint(string(id), 10, 64); err != nil || got != want {
+		return &ProtocolError{Method: method, Reason: fmt.Sprintf("expected id %d, got %s", want, id)}
+	}
+	return nil
+}
+
+// maxErrorBodyLength caps how much of a failed HTTP response body is kept in errors.
+const maxErrorBodyLength = 512
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
+
+type nopCloser struct {
+	io.Reader
+}
+
+func (nopCloser) Close() error { return nil }
@@ -1,7 +1,12 @@
-package main
+package rpc
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -33,7 +38,7 @@ func TestRPCethBlockNumber(t *testing.T) {
 		"method":  "eth_blockNumber",
 		"id":      2,
 	}
-	resp, err := makeRPCRequest(client, server.URL, reqBody)
+	resp, err := makeRPCRequest(context.Background(), client, server.URL, reqBody)
 	if err != nil {
 		t.Fatalf("MakeRPCRequest returned unexpected error: %v", err)
 	}
@@ -99,14 +104,122 @@ func TestRPCethBlockNumberMistake(t *testing.T) {
 		"method":  "eth_blockNumberMistake",
 		"id":      2,
 	}
-	resp, err := makeRPCRequest(client, server.URL, reqBody)
-	if err != nil {
-		t.Fatalf("MakeRPCRequest returned unexpected error: %v", err)
+	resp, err := makeRPCRequest(context.Background(), client, server.URL, reqBody)
+	if resp != nil {
+		t.Errorf("expected no response body, got %q", resp)
 	}
 
-	expectedResp := `{"jsonrpc":"2.0","id":2,"error":{"code":-32601,"message":"the method eth_blockNumberMistake does not exist/is not available"}}`
-	if string(resp) != expectedResp {
-		t.Errorf("expected response %q, got %q", expectedResp, resp)
+	var rpcErr *RPCError
+	if !errors.As(err, &rpcErr) {
+		t.Fatalf("expected an RPCError, got %v", err)
+	}
+	if rpcErr.Code != CodeMethodNotFound {
+		t.Errorf("expected error code %d, got %d", CodeMethodNotFound, rpcErr.Code)
+	}
+	if rpcErr.Message != "the method eth_blockNumberMistake does not exist/is not available" {
+		t.Errorf("unexpected error message %q", rpcErr.Message)
+	}
+	if !errors.Is(err, ErrMethodNotFound) {
+		t.Errorf("expected error to match ErrMethodNotFound")
+	}
+}
+
+func TestRPCHTTPStatusError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, err := w.Write([]byte("slow down"))
+		if err != nil {
+			t.Errorf("error writing response: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	client := server.Client()
+	reqBody := map[string]interface{}{
+		"jsonrpc": "2.0",
+		"method":  "eth_blockNumber",
+		"id":      1,
+	}
+	_, err := makeRPCRequest(context.Background(), client, server.URL, reqBody)
+
+	var statusErr *HTTPStatusError
+	if !errors.As(err, &statusErr) {
+		t.Fatalf("expected an HTTPStatusError, got %v", err)
+	}
+	if statusErr.StatusCode != http.StatusTooManyRequests || statusErr.Body != "slow down" {
+		t.Errorf("unexpected status error %+v", statusErr)
+	}
+	if !errors.Is(err, ErrRateLimited) {
+		t.Errorf("expected error to match ErrRateLimited")
+	}
+}
+
+func TestRPCErrorIs(t *testing.T) {
+	tests := []struct {
+		err    *RPCError
+		target error
+	}{
+		{err: &RPCError{Code: -32601, Message: "method not found"}, target: ErrMethodNotFound},
+		{err: &RPCError{Code: -32005, Message: "limit exceeded"}, target: ErrRateLimited},
+		{err: &RPCError{Code: -32000, Message: "Too Many Requests"}, target: ErrRateLimited},
+		{err: &RPCError{Code: -32000, Message: "header not found"}, target: ErrHeaderNotFound},
+		{err: &RPCError{Code: -32000, Message: "header for hash not found"}, target: ErrHeaderNotFound},
+		{err: &RPCError{Code: 3, Message: "execution reverted: ERC20: transfer amount exceeds balance"}, target: ErrExecutionReverted},
+		{err: &RPCError{Code: -32000, Message: "execution reverted"}, target: ErrExecutionReverted},
+	}
+
+	for _, tt := range tests {
+		if !errors.Is(tt.err, tt.target) {
+			t.Errorf("expected %v to match %v", tt.err, tt.target)
+		}
+	}
+
+	if errors.Is(&RPCError{Code: -32000, Message: "nonce too low"}, ErrHeaderNotFound) {
+		t.Errorf("expected unrelated server error not to match ErrHeaderNotFound")
+	}
+}
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		kind string
+		code string
+	}{
+		{"transport", errors.New("connection refused"), "transport", ""},
+		{"HTTP status", &HTTPStatusError{StatusCode: 429}, "http_status", "429"},
+		{"JSON-RPC", fmt.Errorf("all endpoints failed: %w", &RPCError{Code: -32000}), "jsonrpc", "-32000"},
+		{"protocol", &ProtocolError{Method: "eth_blockNumber"}, "protocol", ""},
+		{"decode", &DecodeError{Method: "eth_blockNumber", Err: errors.New("unexpected end of JSON input")}, "decode", ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			kind, code := ClassifyError(test.err)
+			if kind != test.kind || code != test.code {
+				t.Errorf("expected %s %q, got %s %q", test.kind, test.code, kind, code)
+			}
+		})
+	}
+}
+
+func TestErrorAttr(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	logger.Info("request failed", ErrorAttr(&RPCError{Code: -32005, Message: "limit exceeded"}))
+
+	var entry struct {
+		Error struct {
+			Message string `json:"message"`
+			Type    string `json:"type"`
+			Code    string `json:"code"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("error decoding log entry: %v", err)
+	}
+	if entry.Error.Type != "jsonrpc" || entry.Error.Code != "-32005" || entry.Error.Message == "" {
+		t.Errorf("expected a jsonrpc error with code -32005, got %+v", entry.Error)
 	}
 }
 
@@ -136,7 +249,7 @@ func TestRPCethGetBlockByNumber(t *testing.T) {
 		"method":  "eth_blockNumber",
 		"id":      2,
 	}
-	resp, err := makeRPCRequest(client, server.URL, reqBody)
+	resp, err := makeRPCRequest(context.Background(), client, server.URL, reqBody)
 	if err != nil {
 		t.Fatalf("MakeRPCRequest returned unexpected error: %v", err)
 	}
@@ -145,4 +258,24 @@ func TestRPCethGetBlockByNumber(t *testing.T) {
 	if string(resp) != expectedResp {
 		t.Errorf("expected response %q, got %q", expectedResp, resp)
 	}
+
+	// The block was requested with hashes only, it must still decode
+	var blockResp struct {
+		Result Block `json:"result"`
+	}
+	if err := json.Unmarshal(resp, &blockResp); err != nil {
+		t.Fatalf("error unmarshalling block: %v", err)
+	}
+	if blockResp.Result.ReceiptsRoot.String() != "0xf54bf69fdc660078853ec0baa2dd78f76b6dd76b1a65fc24dd4eea48c29e5945" {
+		t.Errorf("unexpected receipts root %s", blockResp.Result.ReceiptsRoot)
+	}
+	if n := blockResp.Result.Transactions.Len(); n != 67 {
+		t.Errorf("expected 67 transactions, got %d", n)
+	}
+	if _, ok := blockResp.Result.Transactions.Full(); ok {
+		t.Errorf("expected full transactions to be unavailable")
+	}
+	if hashes := blockResp.Result.Transactions.Hashes(); hashes[0].String() != "0x50e7d90746c62550262e436912b3e6e7d55cdcfbbfa53d7299f4c68c48ddf050" {
+		t.Errorf("unexpected first transaction hash %s", hashes[0])
+	}
 }
@@ -0,0 +1,53 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// BalanceAt returns the balance of the account at the block, in wei.
+func (c *Client) BalanceAt(ctx context.Context, account Address, block BlockTag) (*big.Int, error) {
+	balance, err := Send[BigInt](ctx, c, Request{Method: "eth_getBalance", Params: []interface{}{account, block}})
+	if err != nil {
+		return nil, err
+	}
+	return balance.ToInt(), nil
+}
+
+// NonceAt returns the number of transactions sent by the account up to the block, which is the nonce of its next transaction.
+func (c *Client) NonceAt(ctx context.Context, account Address, block BlockTag) (uint64, error) {
+	nonce, err := Send[Quantity](ctx, c, Request{Method: "eth_getTransactionCount", Params: []interface{}{account, block}})
+	if err != nil {
+		return 0, err
+	}
+	return nonce.Uint64(), nil
+}
+
+// CodeAt returns the code of the contract at the block, empty for accounts that are not contracts.
+func (c *Client) CodeAt(ctx context.Context, account Address, block BlockTag) (Bytes, error) {
+	return Send[Bytes](ctx, c, Request{Method: "eth_getCode", Params: []interface{}{account, block}})
+}
+
+// StorageAt returns the value of the storage slot of the contract at the block.
+func (c *Client) StorageAt(ctx context.Context, account Address, slot Hash, block BlockTag) (Hash, error) {
+	raw, err := Send[string](ctx, c, Request{Method: "eth_getStorageAt", Params: []interface{}{account, slot, block}})
+	if err != nil {
+		return Hash{}, err
+	}
+	// Values are left padded to 32 bytes by most nodes, others send them as quantities such as 0x1
+	if len(raw)%2 != 0 && strings.HasPrefix(raw, "0x") {
+		raw = "0x0" + raw[2:]
+	}
+	var value Bytes
+	if err := value.UnmarshalText([]byte(raw)); err != nil {
+		return Hash{}, &DecodeError{Method: "eth_getStorageAt", Err: err}
+	}
+	if len(value) > HashLength {
+		return Hash{}, &DecodeError{Method: "eth_getStorageAt", Err: fmt.Errorf("expected at most %d bytes, got %d", HashLength, len(value))}
+	}
+	var h Hash
+	copy(h[HashLength-len(value):], value)
+	return h, nil
+}
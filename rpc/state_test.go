@@ -0,0 +1,111 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/rafaribe/polygon-client/rpc/internal/rpctest"
+)
+
+func TestAccountState(t *testing.T) {
+	params := map[string]string{}
+	recorded := func(method, result string) rpctest.Handler {
+		return func(req rpctest.Request) (interface{}, error) {
+			encoded, err := json.Marshal(req.Params)
+			if err != nil {
+				return nil, err
+			}
+			params[method] = string(encoded)
+			return json.RawMessage(result), nil
+		}
+	}
+	server := rpctest.NewServer(t, rpctest.Handlers{
+		"eth_getBalance":          recorded("eth_getBalance", `"0xde0b6b3a7640000"`),
+		"eth_getTransactionCount": recorded("eth_getTransactionCount", `"0x2a"`),
+		"eth_getCode":             recorded("eth_getCode", `"0x6080604052"`),
+		"eth_getStorageAt":        recorded("eth_getStorageAt", `"0x01"`),
+	})
+
+	client, err := NewClient([]string{server.URL})
+	if err != nil {
+		t.Fatalf("NewClient returned unexpected error: %v", err)
+	}
+	ctx := context.Background()
+	account, _ := HexToAddress("0x5a52e96bacdabb82fd05763e25335261b270efcb")
+
+	balance, err := client.BalanceAt(ctx, account, FinalizedBlock)
+	if err != nil {
+		t.Fatalf("BalanceAt returned unexpected error: %v", err)
+	}
+	if balance.String() != "1000000000000000000" {
+		t.Errorf("expected balance 1000000000000000000, got %s", balance)
+	}
+
+	nonce, err := client.NonceAt(ctx, account, BlockNumberTag(0x3a9f1c2))
+	if err != nil {
+		t.Fatalf("NonceAt returned unexpected error: %v", err)
+	}
+	if nonce != 42 {
+		t.Errorf("expected nonce 42, got %d", nonce)
+	}
+
+	code, err := client.CodeAt(ctx, account, BlockHashTag(mustHash(t, testBlockHash), true))
+	if err != nil {
+		t.Fatalf("CodeAt returned unexpected error: %v", err)
+	}
+	if code.String() != "0x6080604052" {
+		t.Errorf("expected code 0x6080604052, got %s", code)
+	}
+
+	value, err := client.StorageAt(ctx, account, Hash{}, PendingBlock)
+	if err != nil {
+		t.Fatalf("StorageAt returned unexpected error: %v", err)
+	}
+	if value != (Hash{31: 1}) {
+		t.Errorf("expected a left padded value, got %s", value)
+	}
+
+	expected := map[string]string{
+		"eth_getBalance":          `["0x5a52e96bacdabb82fd05763e25335261b270efcb","finalized"]`,
+		"eth_getTransactionCount": `["0x5a52e96bacdabb82fd05763e25335261b270efcb","0x3a9f1c2"]`,
+		"eth_getCode":             `["0x5a52e96bacdabb82fd05763e25335261b270efcb",{"blockHash":"` + testBlockHash + `","requireCanonical":true}]`,
+		"eth_getStorageAt":        `["0x5a52e96bacdabb82fd05763e25335261b270efcb","0x0000000000000000000000000000000000000000000000000000000000000000","pending"]`,
+	}
+	for method, want := range expected {
+		if params[method] != want {
+			t.Errorf("expected %s params %s, got %s", method, want, params[method])
+		}
+	}
+}
+
+func TestStorageAtShortValues(t *testing.T) {
+	tests := []struct {
+		result   string
+		expected Hash
+	}{
+		{`"0x0000000000000000000000000000000000000000000000000000000000000001"`, Hash{31: 1}},
+		{`"0x01"`, Hash{31: 1}},
+		// Some nodes send storage values as quantities, with an odd number of digits
+		{`"0x1"`, Hash{31: 1}},
+		{`"0x123"`, Hash{30: 0x01, 31: 0x23}},
+		{`"0x0"`, Hash{}},
+		{`"0x"`, Hash{}},
+	}
+
+	for _, tt := range tests {
+		server := rpctest.NewServer(t, rpctest.Handlers{"eth_getStorageAt": rpctest.Result(tt.result)})
+		client, err := NewClient([]string{server.URL})
+		if err != nil {
+			t.Fatalf("NewClient returned unexpected error: %v", err)
+		}
+		value, err := client.StorageAt(context.Background(), Address{}, Hash{}, LatestBlock)
+		if err != nil {
+			t.Errorf("%s: StorageAt returned unexpected error: %v", tt.result, err)
+			continue
+		}
+		if value != tt.expected {
+			t.Errorf("%s: expected %s, got %s", tt.result, tt.expected, value)
+		}
+	}
+}
@@ -0,0 +1,282 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// maxPollCatchUp caps how many blocks a polling subscription goes back to when it falls behind.
+const maxPollCatchUp = 128
+
+// Subscription is a stream of notifications, delivered either over WebSocket or by polling over HTTP.
+type Subscription struct {
+	err         chan error
+	quit        chan struct{}
+	once        sync.Once
+	unsubscribe func()
+}
+
+func newSubscription(unsubscribe func()) *Subscription {
+	return &Subscription{
+		err:         make(chan error, 1),
+		quit:        make(chan struct{}),
+		unsubscribe: unsubscribe,
+	}
+}
+
+// Err returns a channel receiving the error that ended the subscription, it is closed once the subscription ends.
+func (s *Subscription) Err() <-chan error {
+	return s.err
+}
+
+// Unsubscribe ends the subscription, no more notifications are delivered once it returns.
+func (s *Subscription) Unsubscribe() {
+	s.end(nil)
+}
+
+func (s *Subscription) fail(err error) {
+	s.end(err)
+}
+
+func (s *Subscription) end(err error) {
+	s.once.Do(func() {
+		close(s.quit)
+		if s.unsubscribe != nil {
+			s.unsubscribe()
+		}
+		if err != nil {
+			s.err <- err
+		}
+		close(s.err)
+	})
+}
+
+// WithWebSocket sets the WebSocket endpoint used for subscriptions.
+// Without it, or when it cannot be reached, subscriptions fall back to polling over HTTP.
+func WithWebSocket(url string) Option {
+	return func(c *Client) {
+		c.wsURL = url
+	}
+}
+
+// WithPollInterval sets how often subscriptions falling back to HTTP poll for new data.
+func WithPollInterval(interval time.Duration) Option {
+	return func(c *Client) {
+		c.pollInterval = interval
+	}
+}
+
+// SubscribeNewHeads delivers every new block header, without transactions, on ch.
+func (c *Client) SubscribeNewHeads(ctx context.Context, ch chan<- *Block) (*Subscription, error) {
+	return c.subscribe(ctx, []interface{}{"newHeads"}, deliverTo(ch), func() (*Subscription, error) {
+		return c.pollNewHeads(ch), nil
+	})
+}
+
+// SubscribeLogs delivers the logs matching the query on ch, the block range of the query is ignored.
+func (c *Client) SubscribeLogs(ctx context.Context, q FilterQuery, ch chan<- Log) (*Subscription, error) {
+	q.BlockHash, q.FromBlock, q.ToBlock = nil, nil, nil
+	return c.subscribe(ctx, []interface{}{"logs", q}, deliverTo(ch), func() (*Subscription, error) {
+		return c.pollLogs(q, ch), nil
+	})
+}
+
+// SubscribeNewPendingTransactions delivers the hash of every transaction entering the mempool on ch.
+func (c *Client) SubscribeNewPendingTransactions(ctx context.Context, ch chan<- Hash) (*Subscription, error) {
+	return c.subscribe(ctx, []interface{}{"newPendingTransactions"}, deliverTo(ch), func() (*Subscription, error) {
+		return c.pollPendingTransactions(ctx, ch)
+	})
+}
+
+// subscribe subscribes over WebSocket when configured, falling back to the HTTP polling started by poll.
+func (c *Client) subscribe(ctx context.Context, params []interface{}, deliver func(json.RawMessage, <-chan struct{}) error, poll func() (*Subscription, error)) (*Subscription, error) {
+	if c.wsURL == "" {
+		return poll()
+	}
+
+	ws, err := c.websocket(ctx)
+	if err == nil {
+		var sub *Subscription
+		if sub, err = ws.subscribe(ctx, params, deliver); err == nil {
+			return sub, nil
+		}
+	}
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	slog.Warn("WebSocket subscription unavailable, falling back to HTTP polling", "subscription", params[0], "error", err)
+	return poll()
+}
+
+// websocket returns the WebSocket connection, dialing it on first use.
+func (c *Client) websocket(ctx context.Context) (*wsConn, error) {
+	c.wsMu.Lock()
+	defer c.wsMu.Unlock()
+
+	if c.ws != nil {
+		return c.ws, nil
+	}
+	ws, err := dialWS(ctx, c.wsURL, c.retry, func() uint64 { return c.nextIDs(1) })
+	if err != nil {
+		return nil, err
+	}
+	c.ws = ws
+	return ws, nil
+}
+
+// Close releases the WebSocket connection, if any. Subscriptions should be ended before.
+func (c *Client) Close() {
+	c.wsMu.Lock()
+	defer c.wsMu.Unlock()
+
+	if c.ws != nil {
+		c.ws.close()
+		c.ws = nil
+	}
+}
+
+// deliverTo decodes notifications into T and sends them on ch.
+func deliverTo[T any](ch chan<- T) func(json.RawMessage, <-chan struct{}) error {
+	return func(raw json.RawMessage, quit <-chan struct{}) error {
+		var v T
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return err
+		}
+		select {
+		case ch <- v:
+		case <-quit:
+		}
+		return nil
+	}
+}
+
+// pollSubscription calls poll every poll interval until the subscription ends.
+// Polling errors are logged and retried on the next tick, like a WebSocket that reconnects.
+func (c *Client) pollSubscription(poll func(ctx context.Context, quit <-chan struct{}) error) *Subscription {
+	ctx, cancel := context.WithCancel(context.Background())
+	sub := newSubscription(cancel)
+
+	go func() {
+		ticker := time.NewTicker(c.pollInterval)
+		defer ticker.Stop()
+		for {
+			if err := poll(ctx, sub.quit); err != nil && ctx.Err() == nil {
+				slog.Warn("error polling subscription", ErrorAttr(err))
+			}
+			select {
+			case <-sub.quit:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return sub
+}
+
+func (c *Client) pollNewHeads(ch chan<- *Block) *Subscription {
+	var last uint64
+	return c.pollSubscription(func(ctx context.Context, quit <-chan struct{}) error {
+		head, err := c.BlockNumber(ctx)
+		if err != nil {
+			return err
+		}
+		if last == 0 && head > 0 {
+			last = head - 1
+		}
+		// An endpoint behind the one polled before reports an older head, its blocks were already delivered
+		if head <= last {
+			return nil
+		}
+		if head-last > maxPollCatchUp {
+			last = head - maxPollCatchUp
+		}
+
+		for number := last + 1; number <= head; number++ {
+			block, err := c.BlockByNumber(ctx, number, false)
+			if err != nil {
+				return err
+			}
+			select {
+			case ch <- block:
+			case <-quit:
+				return nil
+			}
+			last = number
+		}
+		return nil
+	})
+}
+
+func (c *Client) pollLogs(q FilterQuery, ch chan<- Log) *Subscription {
+	var last uint64
+	return c.pollSubscription(func(ctx context.Context, quit <-chan struct{}) error {
+		head, err := c.BlockNumber(ctx)
+		if err != nil {
+			return err
+		}
+		// Only logs of blocks produced after subscribing are delivered
+		if last == 0 {
+			last = head
+			return nil
+		}
+		if head <= last {
+			return nil
+		}
+		if head-last > maxPollCatchUp {
+			last = head - maxPollCatchUp
+		}
+
+		from, to := last+1, head
+		q.FromBlock, q.ToBlock = &from, &to
+		var logs []Log
+		if err := c.Do(ctx, "eth_getLogs", &logs, q); err != nil {
+			return err
+		}
+		for _, l := range logs {
+			select {
+			case ch <- l:
+			case <-quit:
+				return nil
+			}
+		}
+		last = head
+		return nil
+	})
+}
+
+// pollPendingTransactions relies on a pending transaction filter, which not every endpoint supports.
+func (c *Client) pollPendingTransactions(ctx context.Context, ch chan<- Hash) (*Subscription, error) {
+	var filterID string
+	if err := c.Do(ctx, "eth_newPendingTransactionFilter", &filterID); err != nil {
+		return nil, err
+	}
+
+	return c.pollSubscription(func(ctx context.Context, quit <-chan struct{}) error {
+		var hashes []Hash
+		err := c.Do(ctx, "eth_getFilterChanges", &hashes, filterID)
+		var rpcErr *RPCError
+		if errors.As(err, &rpcErr) {
+			// Filters expire when not polled for a while, or live on another endpoint of the pool
+			if err := c.Do(ctx, "eth_newPendingTransactionFilter", &filterID); err != nil {
+				return err
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		for _, hash := range hashes {
+			select {
+			case ch <- hash:
+			case <-quit:
+				return nil
+			}
+		}
+		return nil
+	}), nil
+}
@@ -0,0 +1,356 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/rafaribe/polygon-client/rpc/internal/rpctest"
+)
+
+// requestIDs records the ids of the requests received by servers.
+type requestIDs struct {
+	mu  sync.Mutex
+	ids []uint64
+}
+
+func (r *requestIDs) add(t *testing.T, req rpctest.Request) {
+	var id uint64
+	if err := json.Unmarshal(req.ID, &id); err != nil {
+		t.Errorf("error decoding request id: %v", err)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ids = append(r.ids, id)
+}
+
+func (r *requestIDs) get() []uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]uint64(nil), r.ids...)
+}
+
+// newWSServer accepts eth_subscribe calls, recording their ids, and sends one newHeads notification per connection,
+// numbered after the connection.
+// The first connection is dropped right after its notification to exercise reconnection,
+// the first failures eth_subscribe calls of the following connections fail.
+func newWSServer(t *testing.T, subscribes *requestIDs, failures int) *httptest.Server {
+	t.Helper()
+	var connections, failed atomic.Int32
+	upgrader := websocket.Upgrader{}
+	handlers := rpctest.Handlers{"eth_unsubscribe": rpctest.Result(`true`)}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("error upgrading connection: %v", err)
+			return
+		}
+		defer conn.Close()
+		connection := connections.Add(1)
+
+		for {
+			_, message, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			var req rpctest.Request
+			if err := json.Unmarshal(message, &req); err != nil {
+				t.Errorf("error decoding request: %v", err)
+				return
+			}
+			if req.Method != "eth_subscribe" {
+				resp, err := handlers.Dispatch(message)
+				if err != nil {
+					t.Errorf("error dispatching request: %v", err)
+					return
+				}
+				if err := conn.WriteMessage(websocket.TextMessage, resp); err != nil {
+					return
+				}
+				continue
+			}
+			subscribes.add(t, req)
+			if connection > 1 && failed.Add(1) <= int32(failures) {
+				resp := map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "error": rpctest.Error{Code: CodeServerError, Message: "too many subscriptions"}}
+				if err := conn.WriteJSON(resp); err != nil {
+					return
+				}
+				continue
+			}
+
+			id := fmt.Sprintf("0x%x", connection)
+			if err := conn.WriteJSON(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": id}); err != nil {
+				return
+			}
+			notification := fmt.Sprintf(`{"jsonrpc":"2.0","method":"eth_subscription","params":{"subscription":%q,"result":{"number":"0x%x","hash":"0xe1efb3e3e0e76e7578a6c9216755bf25d22cb0c43dff9aff4f62de507e846d4f","timestamp":"0x61698316"}}}`, id, connection)
+			if err := conn.WriteMessage(websocket.TextMessage, []byte(notification)); err != nil {
+				return
+			}
+			if connection == 1 {
+				return
+			}
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestSubscribeNewHeadsReconnects(t *testing.T) {
+	var subscribes, blockNumbers requestIDs
+	server := newWSServer(t, &subscribes, 0)
+	httpServer := rpctest.NewServer(t, rpctest.Handlers{
+		"eth_blockNumber": func(req rpctest.Request) (interface{}, error) {
+			blockNumbers.add(t, req)
+			return Quantity(1), nil
+		},
+	})
+
+	client, err := NewClient([]string{httpServer.URL},
+		WithWebSocket("ws"+strings.TrimPrefix(server.URL, "http")),
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 1, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}),
+	)
+	if err != nil {
+		t.Fatalf("NewClient returned unexpected error: %v", err)
+	}
+	defer client.Close()
+
+	if _, err := client.BlockNumber(context.Background()); err != nil {
+		t.Fatalf("BlockNumber returned unexpected error: %v", err)
+	}
+	heads := make(chan *Block)
+	sub, err := client.SubscribeNewHeads(context.Background(), heads)
+	if err != nil {
+		t.Fatalf("SubscribeNewHeads returned unexpected error: %v", err)
+	}
+	defer sub.Unsubscribe()
+
+	for _, expected := range []Quantity{1, 2} {
+		select {
+		case head := <-heads:
+			if head.Number != expected {
+				t.Errorf("expected head %d, got %d", expected, head.Number)
+			}
+		case err := <-sub.Err():
+			t.Fatalf("subscription failed: %v", err)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for head %d", expected)
+		}
+	}
+
+	if _, err := client.BlockNumber(context.Background()); err != nil {
+		t.Fatalf("BlockNumber returned unexpected error: %v", err)
+	}
+
+	subscribeIDs := subscribes.get()
+	if len(subscribeIDs) != 2 {
+		t.Fatalf("expected the subscription to be renewed once, got %d subscribes", len(subscribeIDs))
+	}
+	// Ids are shared by HTTP and WebSocket requests and keep increasing across reconnections
+	ids := blockNumbers.get()
+	ids = []uint64{ids[0], subscribeIDs[0], subscribeIDs[1], ids[1]}
+	if ids[0] != 1 || ids[1] != 2 || ids[2] != 3 || ids[3] != 4 {
+		t.Errorf("expected ids [1 2 3 4], got %v", ids)
+	}
+}
+
+func TestSubscribeNewHeadsRetriesResubscribe(t *testing.T) {
+	var subscribes requestIDs
+	server := newWSServer(t, &subscribes, 2)
+
+	client, err := NewClient([]string{"http://127.0.0.1:1"},
+		WithWebSocket("ws"+strings.TrimPrefix(server.URL, "http")),
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 1, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}),
+	)
+	if err != nil {
+		t.Fatalf("NewClient returned unexpected error: %v", err)
+	}
+	defer client.Close()
+
+	heads := make(chan *Block)
+	sub, err := client.SubscribeNewHeads(context.Background(), heads)
+	if err != nil {
+		t.Fatalf("SubscribeNewHeads returned unexpected error: %v", err)
+	}
+	defer sub.Unsubscribe()
+
+	for _, expected := range []Quantity{1, 2} {
+		select {
+		case head := <-heads:
+			if head.Number != expected {
+				t.Errorf("expected head %d, got %d", expected, head.Number)
+			}
+		case err := <-sub.Err():
+			t.Fatalf("subscription failed: %v", err)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for head %d", expected)
+		}
+	}
+
+	if n := len(subscribes.get()); n != 4 {
+		t.Errorf("expected the failed resubscribes to be retried, got %d subscribes", n)
+	}
+}
+
+func TestWSCallProtocolError(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+	}{
+		{"unknown id", `{"jsonrpc":"2.0","id":42,"result":"0x1"}`},
+		{"null id", `{"jsonrpc":"2.0","id":null,"error":{"code":-32700,"message":"parse error"}}`},
+		{"missing id", `{"jsonrpc":"2.0","result":"0x1"}`},
+		{"wrong version", `{"jsonrpc":"1.0","id":1,"result":"0x1"}`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			upgrader := websocket.Upgrader{}
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				conn, err := upgrader.Upgrade(w, r, nil)
+				if err != nil {
+					t.Errorf("error upgrading connection: %v", err)
+					return
+				}
+				defer conn.Close()
+				if _, _, err := conn.ReadMessage(); err != nil {
+					return
+				}
+				if err := conn.WriteMessage(websocket.TextMessage, []byte(test.response)); err != nil {
+					return
+				}
+				// Keep the connection open, the call must not wait for it to drop
+				conn.ReadMessage()
+			}))
+			defer server.Close()
+
+			var lastID uint64
+			ws, err := dialWS(context.Background(), "ws"+strings.TrimPrefix(server.URL, "http"), DefaultRetryPolicy, func() uint64 {
+				lastID++
+				return lastID
+			})
+			if err != nil {
+				t.Fatalf("dialWS returned unexpected error: %v", err)
+			}
+			defer ws.close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			var result Quantity
+			err = ws.call(ctx, "eth_blockNumber", nil, &result)
+			var protocolErr *ProtocolError
+			if !errors.As(err, &protocolErr) {
+				t.Errorf("expected a ProtocolError, got %v", err)
+			}
+		})
+	}
+}
+
+func TestSubscribeNewHeadsFallsBackToPolling(t *testing.T) {
+	var mu sync.Mutex
+	head := uint64(10)
+	server := rpctest.NewServer(t, rpctest.Handlers{
+		"eth_blockNumber": func(rpctest.Request) (interface{}, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			number := Quantity(head)
+			head += 2
+			return number, nil
+		},
+		"eth_getBlockByNumber": func(req rpctest.Request) (interface{}, error) {
+			var number string
+			if err := req.Param(0, &number); err != nil {
+				return nil, err
+			}
+			return json.RawMessage(fmt.Sprintf(`{"number":%q,"timestamp":"0x61698316","transactions":[]}`, number)), nil
+		},
+	})
+
+	// The WebSocket endpoint cannot be reached, polling takes over
+	client, err := NewClient([]string{server.URL}, WithWebSocket("ws://127.0.0.1:1"), WithPollInterval(10*time.Millisecond))
+	if err != nil {
+		t.Fatalf("NewClient returned unexpected error: %v", err)
+	}
+	defer client.Close()
+
+	heads := make(chan *Block)
+	sub, err := client.SubscribeNewHeads(context.Background(), heads)
+	if err != nil {
+		t.Fatalf("SubscribeNewHeads returned unexpected error: %v", err)
+	}
+	defer sub.Unsubscribe()
+
+	// Heads skipped between two polls are delivered too
+	for _, expected := range []Quantity{10, 11, 12, 13} {
+		select {
+		case head := <-heads:
+			if head.Number != expected {
+				t.Errorf("expected head %d, got %d", expected, head.Number)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for head %d", expected)
+		}
+	}
+}
+
+func TestSubscribeNewHeadsIgnoresOlderHead(t *testing.T) {
+	var mu sync.Mutex
+	// The third poll reaches an endpoint lagging behind the others
+	reported := []uint64{10, 12, 5, 13}
+	server := rpctest.NewServer(t, rpctest.Handlers{
+		"eth_blockNumber": func(rpctest.Request) (interface{}, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			head := reported[0]
+			if len(reported) > 1 {
+				reported = reported[1:]
+			}
+			return Quantity(head), nil
+		},
+		"eth_getBlockByNumber": func(req rpctest.Request) (interface{}, error) {
+			var number string
+			if err := req.Param(0, &number); err != nil {
+				return nil, err
+			}
+			return json.RawMessage(fmt.Sprintf(`{"number":%q,"timestamp":"0x61698316","transactions":[]}`, number)), nil
+		},
+	})
+
+	client, err := NewClient([]string{server.URL}, WithPollInterval(10*time.Millisecond))
+	if err != nil {
+		t.Fatalf("NewClient returned unexpected error: %v", err)
+	}
+	defer client.Close()
+
+	heads := make(chan *Block)
+	sub, err := client.SubscribeNewHeads(context.Background(), heads)
+	if err != nil {
+		t.Fatalf("SubscribeNewHeads returned unexpected error: %v", err)
+	}
+	defer sub.Unsubscribe()
+
+	for _, expected := range []Quantity{10, 11, 12, 13} {
+		select {
+		case head := <-heads:
+			if head.Number != expected {
+				t.Fatalf("expected head %d, got %d", expected, head.Number)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for head %d", expected)
+		}
+	}
+	select {
+	case head := <-heads:
+		t.Errorf("expected no more heads, got %d", head.Number)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
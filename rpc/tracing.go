@@ -0,0 +1,69 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName identifies the spans created by this package.
+const tracerName = "github.com/rafaribe/polygon-client/rpc"
+
+// WithTracerProvider sets the provider of the tracer creating a span for every request sent to an endpoint.
+// Clients created without it use the global provider, which does nothing unless one is installed with otel.SetTracerProvider.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(c *Client) {
+		c.pool.tracer = tp.Tracer(tracerName)
+	}
+}
+
+// defaultTracer returns the tracer of the global provider, which follows the provider installed later on, if any.
+func defaultTracer() trace.Tracer {
+	return otel.Tracer(tracerName)
+}
+
+// startSpan starts the span of a request sent to an endpoint, named after its method.
+// The endpoint is given by its name, as in metrics and logs, spans are exported to third parties.
+func startSpan(ctx context.Context, tracer trace.Tracer, method, endpoint string, reqBody interface{}) (context.Context, trace.Span) {
+	attrs := []attribute.KeyValue{
+		attribute.String("rpc.system", "jsonrpc"),
+		attribute.String("rpc.method", method),
+		attribute.String("rpc.jsonrpc.version", "2.0"),
+		attribute.String("url.full", endpoint),
+	}
+	switch req := reqBody.(type) {
+	case wireRequest:
+		attrs = append(attrs, attribute.String("rpc.jsonrpc.request_id", strconv.FormatUint(req.ID, 10)))
+	case []wireRequest:
+		attrs = append(attrs, attribute.Int("rpc.jsonrpc.batch_size", len(req)))
+	}
+	return tracer.Start(ctx, method, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+}
+
+// endSpan records the outcome of a request on its span and ends it.
+// Failed requests carry the error type, as returned by ClassifyError, and the HTTP status or JSON-RPC error code.
+func endSpan(span trace.Span, respBody []byte, err error) {
+	defer span.End()
+	if err == nil {
+		span.SetAttributes(attribute.Int("http.response.body.size", len(respBody)))
+		return
+	}
+
+	kind, _ := ClassifyError(err)
+	span.SetAttributes(attribute.String("error.type", kind))
+	var statusErr *HTTPStatusError
+	if errors.As(err, &statusErr) {
+		span.SetAttributes(attribute.Int("http.response.status_code", statusErr.StatusCode))
+	}
+	var rpcErr *RPCError
+	if errors.As(err, &rpcErr) {
+		span.SetAttributes(attribute.Int("rpc.jsonrpc.error_code", rpcErr.Code), attribute.String("rpc.jsonrpc.error_message", rpcErr.Message))
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
@@ -0,0 +1,75 @@
+package rpc
+
+import (
+	"math/big"
+)
+
+// Transaction types, see EIP-2718.
+const (
+	LegacyTxType     = 0x00
+	AccessListTxType = 0x01 // EIP-2930
+	DynamicFeeTxType = 0x02 // EIP-1559
+	BlobTxType       = 0x03 // EIP-4844
+)
+
+// Transaction is a transaction included in a block.
+// Fields introduced by typed transactions are nil or empty for the types that do not carry them.
+type Transaction struct {
+	Type Quantity `json:"type"`
+	// BlockHash, BlockNumber and TransactionIndex are nil for pending transactions
+	BlockHash   *Hash     `json:"blockHash"`
+	BlockNumber *Quantity `json:"blockNumber"`
+	From        Address   `json:"from"`
+	Gas         Quantity  `json:"gas"`
+	// GasPrice is set for every type, for dynamic fee transactions included in a block it is the effective gas price
+	GasPrice *BigInt  `json:"gasPrice"`
+	Hash     Hash     `json:"hash"`
+	Input    Bytes    `json:"input"`
+	Nonce    Quantity `json:"nonce"`
+	// To is nil for contract creations
+	To               *Address  `json:"to"`
+	TransactionIndex *Quantity `json:"transactionIndex"`
+	Value            *BigInt   `json:"value"`
+	V                *BigInt   `json:"v"`
+	R                *BigInt   `json:"r"`
+	S                *BigInt   `json:"s"`
+
+	// ChainID is set for every type but legacy transactions without replay protection
+	ChainID *BigInt `json:"chainId,omitempty"`
+	// AccessList is set for access list, dynamic fee and blob transactions
+	AccessList AccessList `json:"accessList,omitempty"`
+	// YParity duplicates V for typed transactions
+	YParity *Quantity `json:"yParity,omitempty"`
+	// MaxFeePerGas and MaxPriorityFeePerGas are set for dynamic fee and blob transactions
+	MaxFeePerGas         *BigInt `json:"maxFeePerGas,omitempty"`
+	MaxPriorityFeePerGas *BigInt `json:"maxPriorityFeePerGas,omitempty"`
+	// MaxFeePerBlobGas and BlobVersionedHashes are set for blob transactions
+	MaxFeePerBlobGas    *BigInt `json:"maxFeePerBlobGas,omitempty"`
+	BlobVersionedHashes []Hash  `json:"blobVersionedHashes,omitempty"`
+}
+
+// AccessList is the list of addresses and storage keys a transaction plans to access, see EIP-2930.
+type AccessList []AccessTuple
+
+// AccessTuple is an entry of an access list.
+type AccessTuple struct {
+	Address     Address `json:"address"`
+	StorageKeys []Hash  `json:"storageKeys"`
+}
+
+// EffectiveGasPrice returns the price per gas paid by the transaction in a block with the given base fee.
+// Transactions with a fee cap pay the base fee plus their tip, up to their cap, others pay their gas price.
+func (tx *Transaction) EffectiveGasPrice(baseFee *big.Int) *big.Int {
+	if tx.MaxFeePerGas == nil || tx.MaxPriorityFeePerGas == nil || baseFee == nil {
+		if tx.GasPrice == nil {
+			return nil
+		}
+		return new(big.Int).Set(tx.GasPrice.ToInt())
+	}
+
+	price := new(big.Int).Add(baseFee, tx.MaxPriorityFeePerGas.ToInt())
+	if feeCap := tx.MaxFeePerGas.ToInt(); price.Cmp(feeCap) > 0 {
+		price.Set(feeCap)
+	}
+	return price
+}
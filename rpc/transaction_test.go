@@ -0,0 +1,94 @@
+package rpc
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+)
+
+func TestTransactionTypes(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		check func(t *testing.T, tx Transaction)
+	}{
+		{
+			name:  "legacy",
+			input: `{"type":"0x0","hash":"0x50e7d90746c62550262e436912b3e6e7d55cdcfbbfa53d7299f4c68c48ddf050","gasPrice":"0x6fc23ac00","v":"0x136","r":"0x1","s":"0x2"}`,
+			check: func(t *testing.T, tx Transaction) {
+				if tx.Type != LegacyTxType || tx.GasPrice.ToInt().Int64() != 30000000000 {
+					t.Errorf("unexpected legacy transaction %+v", tx)
+				}
+				if tx.AccessList != nil || tx.MaxFeePerGas != nil {
+					t.Errorf("expected no typed transaction fields")
+				}
+			},
+		},
+		{
+			name:  "access list",
+			input: `{"type":"0x1","chainId":"0x89","gasPrice":"0x6fc23ac00","accessList":[{"address":"0x0000000000000000000000000000000000001010","storageKeys":["0x0000000000000000000000000000000000000000000000000000000000000001"]}],"yParity":"0x1"}`,
+			check: func(t *testing.T, tx Transaction) {
+				if tx.Type != AccessListTxType || tx.ChainID.ToInt().Int64() != 137 || *tx.YParity != 1 {
+					t.Errorf("unexpected access list transaction %+v", tx)
+				}
+				if len(tx.AccessList) != 1 || len(tx.AccessList[0].StorageKeys) != 1 {
+					t.Errorf("unexpected access list %+v", tx.AccessList)
+				}
+			},
+		},
+		{
+			name:  "dynamic fee",
+			input: `{"type":"0x2","chainId":"0x89","gasPrice":"0x7","maxFeePerGas":"0xa","maxPriorityFeePerGas":"0x2","accessList":[],"yParity":"0x0"}`,
+			check: func(t *testing.T, tx Transaction) {
+				if tx.Type != DynamicFeeTxType || tx.MaxFeePerGas.ToInt().Int64() != 10 || tx.MaxPriorityFeePerGas.ToInt().Int64() != 2 {
+					t.Errorf("unexpected dynamic fee transaction %+v", tx)
+				}
+			},
+		},
+		{
+			name:  "blob",
+			input: `{"type":"0x3","chainId":"0x1","maxFeePerGas":"0xa","maxPriorityFeePerGas":"0x2","maxFeePerBlobGas":"0x3","blobVersionedHashes":["0x01b7c77bb79ec556bfee818ed2bd48ef4f5e0a9a9ff91f1d5a57febb5cf0a6e6"],"accessList":[],"yParity":"0x1"}`,
+			check: func(t *testing.T, tx Transaction) {
+				if tx.Type != BlobTxType || tx.MaxFeePerBlobGas.ToInt().Int64() != 3 || len(tx.BlobVersionedHashes) != 1 {
+					t.Errorf("unexpected blob transaction %+v", tx)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var tx Transaction
+			if err := json.Unmarshal([]byte(tt.input), &tx); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			tt.check(t, tx)
+		})
+	}
+}
+
+func TestEffectiveGasPrice(t *testing.T) {
+	legacy := Transaction{GasPrice: NewBigInt(big.NewInt(30))}
+	dynamic := Transaction{
+		Type:                 DynamicFeeTxType,
+		MaxFeePerGas:         NewBigInt(big.NewInt(40)),
+		MaxPriorityFeePerGas: NewBigInt(big.NewInt(5)),
+	}
+
+	tests := []struct {
+		name     string
+		tx       Transaction
+		baseFee  *big.Int
+		expected int64
+	}{
+		{name: "legacy", tx: legacy, baseFee: big.NewInt(10), expected: 30},
+		{name: "dynamic below cap", tx: dynamic, baseFee: big.NewInt(10), expected: 15},
+		{name: "dynamic capped", tx: dynamic, baseFee: big.NewInt(38), expected: 40},
+	}
+
+	for _, tt := range tests {
+		if got := tt.tx.EffectiveGasPrice(tt.baseFee); got.Int64() != tt.expected {
+			t.Errorf("%s: expected %d, got %s", tt.name, tt.expected, got)
+		}
+	}
+}
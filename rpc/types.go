@@ -0,0 +1,216 @@
+package rpc
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+)
+
+// Errors returned when decoding hex encoded values.
+var (
+	ErrMissingPrefix = errors.New("hex string without 0x prefix")
+	ErrEmptyNumber   = errors.New("hex string \"0x\"")
+	ErrLeadingZero   = errors.New("hex number with leading zero digits")
+	ErrOddLength     = errors.New("hex string of odd length")
+	ErrUint64Range   = errors.New("hex number > 64 bits")
+	ErrSyntax        = errors.New("invalid hex string")
+)
+
+// Quantity is an unsigned integer encoded as a hex string without leading zeros, as per the JSON-RPC spec.
+type Quantity uint64
+
+// Uint64 returns the quantity as a native integer.
+func (q Quantity) Uint64() uint64 {
+	return uint64(q)
+}
+
+func (q Quantity) String() string {
+	return "0x" + strconv.FormatUint(uint64(q), 16)
+}
+
+func (q Quantity) MarshalText() ([]byte, error) {
+	return []byte(q.String()), nil
+}
+
+func (q *Quantity) UnmarshalText(text []byte) error {
+	digits, err := quantityDigits(text)
+	if err != nil {
+		return fmt.Errorf("invalid quantity %q: %w", text, err)
+	}
+	if len(digits) > 16 {
+		return fmt.Errorf("invalid quantity %q: %w", text, ErrUint64Range)
+	}
+	n, err := strconv.ParseUint(digits, 16, 64)
+	if err != nil {
+		return fmt.Errorf("invalid quantity %q: %w", text, ErrSyntax)
+	}
+	*q = Quantity(n)
+	return nil
+}
+
+// BigInt is an arbitrary precision unsigned integer encoded as a quantity.
+type BigInt big.Int
+
+// NewBigInt converts a big.Int, which must not be negative.
+func NewBigInt(i *big.Int) *BigInt {
+	return (*BigInt)(new(big.Int).Set(i))
+}
+
+// ToInt returns the value as a big.Int, sharing its memory.
+func (b *BigInt) ToInt() *big.Int {
+	return (*big.Int)(b)
+}
+
+func (b *BigInt) String() string {
+	if b == nil {
+		return "<nil>"
+	}
+	return "0x" + b.ToInt().Text(16)
+}
+
+func (b *BigInt) MarshalText() ([]byte, error) {
+	if b.ToInt().Sign() < 0 {
+		return nil, fmt.Errorf("invalid quantity %s: negative number", b.ToInt())
+	}
+	return []byte(b.String()), nil
+}
+
+func (b *BigInt) UnmarshalText(text []byte) error {
+	digits, err := quantityDigits(text)
+	if err != nil {
+		return fmt.Errorf("invalid quantity %q: %w", text, err)
+	}
+	if len(digits) > 64 {
+		return fmt.Errorf("invalid quantity %q: hex number > 256 bits", text)
+	}
+	if _, ok := b.ToInt().SetString(digits, 16); !ok {
+		return fmt.Errorf("invalid quantity %q: %w", text, ErrSyntax)
+	}
+	return nil
+}
+
+// quantityDigits validates the quantity encoding and returns its hex digits.
+func quantityDigits(text []byte) (string, error) {
+	if len(text) < 2 || text[0] != '0' || (text[1] != 'x' && text[1] != 'X') {
+		return "", ErrMissingPrefix
+	}
+	digits := string(text[2:])
+	if digits == "" {
+		return "", ErrEmptyNumber
+	}
+	if len(digits) > 1 && digits[0] == '0' {
+		return "", ErrLeadingZero
+	}
+	for _, c := range digits {
+		if !isHexDigit(c) {
+			return "", ErrSyntax
+		}
+	}
+	return digits, nil
+}
+
+func isHexDigit(c rune) bool {
+	return ('0' <= c && c <= '9') || ('a' <= c && c <= 'f') || ('A' <= c && c <= 'F')
+}
+
+// Bytes is an arbitrary byte string encoded as 0x prefixed hex.
+type Bytes []byte
+
+func (b Bytes) String() string {
+	return "0x" + hex.EncodeToString(b)
+}
+
+func (b Bytes) MarshalText() ([]byte, error) {
+	return []byte(b.String()), nil
+}
+
+func (b *Bytes) UnmarshalText(text []byte) error {
+	decoded, err := decodeData(text)
+	if err != nil {
+		return fmt.Errorf("invalid bytes: %w", err)
+	}
+	*b = decoded
+	return nil
+}
+
+// HashLength is the length in bytes of a Keccak-256 hash.
+const HashLength = 32
+
+// Hash is a 32 bytes Keccak-256 hash, of a block or a transaction for instance.
+type Hash [HashLength]byte
+
+// HexToHash parses a 0x prefixed hex hash.
+func HexToHash(s string) (Hash, error) {
+	var h Hash
+	err := h.UnmarshalText([]byte(s))
+	return h, err
+}
+
+func (h Hash) String() string {
+	return "0x" + hex.EncodeToString(h[:])
+}
+
+func (h Hash) MarshalText() ([]byte, error) {
+	return []byte(h.String()), nil
+}
+
+func (h *Hash) UnmarshalText(text []byte) error {
+	return decodeFixed(text, h[:], "hash")
+}
+
+// AddressLength is the length in bytes of an account address.
+const AddressLength = 20
+
+// Address is a 20 bytes account address.
+type Address [AddressLength]byte
+
+// HexToAddress parses a 0x prefixed hex address.
+func HexToAddress(s string) (Address, error) {
+	var a Address
+	err := a.UnmarshalText([]byte(s))
+	return a, err
+}
+
+// String returns the lower case hex form of the address, checksums are not computed.
+func (a Address) String() string {
+	return "0x" + hex.EncodeToString(a[:])
+}
+
+func (a Address) MarshalText() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+func (a *Address) UnmarshalText(text []byte) error {
+	return decodeFixed(text, a[:], "address")
+}
+
+// decodeData decodes 0x prefixed hex of even length.
+func decodeData(text []byte) ([]byte, error) {
+	if len(text) < 2 || text[0] != '0' || (text[1] != 'x' && text[1] != 'X') {
+		return nil, ErrMissingPrefix
+	}
+	text = text[2:]
+	if len(text)%2 != 0 {
+		return nil, ErrOddLength
+	}
+	decoded := make([]byte, len(text)/2)
+	if _, err := hex.Decode(decoded, text); err != nil {
+		return nil, ErrSyntax
+	}
+	return decoded, nil
+}
+
+// decodeFixed decodes 0x prefixed hex into out, which the decoded value must fill exactly.
+func decodeFixed(text []byte, out []byte, kind string) error {
+	decoded, err := decodeData(text)
+	if err != nil {
+		return fmt.Errorf("invalid %s %q: %w", kind, text, err)
+	}
+	if len(decoded) != len(out) {
+		return fmt.Errorf("invalid %s %q: expected %d bytes, got %d", kind, text, len(out), len(decoded))
+	}
+	copy(out, decoded)
+	return nil
+}
@@ -0,0 +1,140 @@
+package rpc
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestQuantityUnmarshal(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected Quantity
+		err      error
+	}{
+		{input: `"0x0"`, expected: 0},
+		{input: `"0x28bb63f"`, expected: 0x28bb63f},
+		{input: `"0xffffffffffffffff"`, expected: 0xffffffffffffffff},
+		{input: `"28bb63f"`, err: ErrMissingPrefix},
+		{input: `"0x"`, err: ErrEmptyNumber},
+		{input: `"0x01"`, err: ErrLeadingZero},
+		{input: `"0x10000000000000000"`, err: ErrUint64Range},
+		{input: `"0xzz"`, err: ErrSyntax},
+	}
+
+	for _, tt := range tests {
+		var q Quantity
+		err := json.Unmarshal([]byte(tt.input), &q)
+		if tt.err != nil {
+			if !errors.Is(err, tt.err) {
+				t.Errorf("%s: expected error %v, got %v", tt.input, tt.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", tt.input, err)
+			continue
+		}
+		if q != tt.expected {
+			t.Errorf("%s: expected %d, got %d", tt.input, tt.expected, q)
+		}
+	}
+
+	var q Quantity
+	if err := json.Unmarshal([]byte(`42`), &q); err == nil {
+		t.Errorf("expected JSON numbers to be rejected")
+	}
+}
+
+func TestQuantityMarshal(t *testing.T) {
+	encoded, err := json.Marshal([]Quantity{0, 0x28bb63f})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(encoded) != `["0x0","0x28bb63f"]` {
+		t.Errorf("unexpected encoding %s", encoded)
+	}
+}
+
+func TestBigIntRoundTrip(t *testing.T) {
+	input := `"0xde0b6b3a7640000000000000000000000000000"`
+	var b BigInt
+	if err := json.Unmarshal([]byte(input), &b); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected, _ := new(big.Int).SetString("de0b6b3a7640000000000000000000000000000", 16)
+	if b.ToInt().Cmp(expected) != 0 {
+		t.Errorf("expected %s, got %s", expected, b.ToInt())
+	}
+
+	encoded, err := json.Marshal(&b)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(encoded) != input {
+		t.Errorf("expected %s, got %s", input, encoded)
+	}
+
+	if err := json.Unmarshal([]byte(`"0x00"`), &b); !errors.Is(err, ErrLeadingZero) {
+		t.Errorf("expected leading zero error, got %v", err)
+	}
+}
+
+func TestFixedLengthTypes(t *testing.T) {
+	var h Hash
+	if err := json.Unmarshal([]byte(`"0xe1efb3e3e0e76e7578a6c9216755bf25d22cb0c43dff9aff4f62de507e846d4f"`), &h); err != nil {
+		t.Errorf("unexpected hash error: %v", err)
+	}
+	if err := json.Unmarshal([]byte(`"0xe1ef"`), &h); err == nil {
+		t.Errorf("expected short hash to be rejected")
+	}
+
+	var a Address
+	if err := json.Unmarshal([]byte(`"0x0000000000000000000000000000000000001010"`), &a); err != nil {
+		t.Errorf("unexpected address error: %v", err)
+	}
+	if a.String() != "0x0000000000000000000000000000000000001010" {
+		t.Errorf("unexpected address %s", a)
+	}
+	if err := json.Unmarshal([]byte(`"0000000000000000000000000000000000001010"`), &a); !errors.Is(err, ErrMissingPrefix) {
+		t.Errorf("expected missing prefix error, got %v", err)
+	}
+
+	var b Bytes
+	if err := json.Unmarshal([]byte(`"0xa9059cbb"`), &b); err != nil || len(b) != 4 {
+		t.Errorf("unexpected bytes %v: %v", b, err)
+	}
+	if err := json.Unmarshal([]byte(`"0xa9059cb"`), &b); !errors.Is(err, ErrOddLength) {
+		t.Errorf("expected odd length error, got %v", err)
+	}
+}
+
+func TestBlockTimestamp(t *testing.T) {
+	var block Block
+	if err := json.Unmarshal([]byte(`{"number":"0x134e82a","timestamp":"0x61698316","gasUsed":"0xe13554"}`), &block); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := time.Date(2021, 10, 15, 13, 33, 10, 0, time.UTC)
+	if !block.Timestamp.Equal(expected) {
+		t.Errorf("expected timestamp %s, got %s", expected, block.Timestamp)
+	}
+	if block.Number != 0x134e82a || block.GasUsed != 0xe13554 {
+		t.Errorf("unexpected block %+v", block)
+	}
+
+	encoded, err := json.Marshal(block)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var decoded Block
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !decoded.Timestamp.Equal(expected) {
+		t.Errorf("expected timestamp to survive a round trip, got %s", decoded.Timestamp)
+	}
+}
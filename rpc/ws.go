@@ -0,0 +1,438 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// wsQueueSize is the number of notifications buffered per subscription before it is considered too slow
+	wsQueueSize = 1024
+	// wsOrphanLimit caps the notifications kept per subscription id that is not registered yet, and the number of such ids
+	wsOrphanLimit = 128
+	// wsResubscribeTimeout bounds each eth_subscribe call made after a reconnection
+	wsResubscribeTimeout = time.Second * 10
+)
+
+var (
+	// ErrSubscriptionQueueOverflow is sent on Err when the consumer of a subscription cannot keep up with its notifications.
+	ErrSubscriptionQueueOverflow = errors.New("subscription queue overflow")
+
+	errWSNotConnected = errors.New("WebSocket not connected")
+)
+
+// wsMessage is any message received on the WebSocket, either a response or a subscription notification.
+type wsMessage struct {
+	Version string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+	Result  json.RawMessage `json:"result"`
+	Error   *RPCError       `json:"error"`
+}
+
+type wsResult struct {
+	msg wsMessage
+	err error
+}
+
+// wsCall is a call waiting for its response.
+type wsCall struct {
+	method string
+	ch     chan wsResult
+}
+
+// wsConn is a JSON-RPC connection over WebSocket used for subscriptions.
+// When the connection drops it reconnects with backoff and subscribes again on behalf of every active subscription.
+type wsConn struct {
+	url     string
+	dialer  *websocket.Dialer
+	backoff RetryPolicy
+	// nextID allocates request ids from the client, so they are unique across HTTP and WebSocket requests
+	nextID func() uint64
+	done   chan struct{}
+
+	// writeMu serializes writes, the WebSocket supports a single concurrent writer
+	writeMu sync.Mutex
+
+	mu sync.Mutex
+	// conn is nil while reconnecting
+	conn   *websocket.Conn
+	closed bool
+	// lastID is the id of the last request sent, replies to higher ids answer no request
+	lastID  uint64
+	pending map[uint64]wsCall
+	// subs maps the server side subscription ids of the current connection to their subscription
+	subs   map[string]*wsSubscription
+	active map[*wsSubscription]struct{}
+	// orphans holds notifications received before their subscription id was registered,
+	// they survive disconnections as the subscription may be registered right after its connection dropped
+	orphans map[string][]json.RawMessage
+}
+
+// wsSubscription is an active eth_subscribe subscription.
+type wsSubscription struct {
+	*Subscription
+	params  []interface{}
+	deliver func(raw json.RawMessage, quit <-chan struct{}) error
+	queue   chan json.RawMessage
+	// id is the server side id, which changes on every reconnection
+	id string
+}
+
+// dialWS connects to the WebSocket endpoint, nextID allocates the ids of its requests.
+func dialWS(ctx context.Context, url string, backoff RetryPolicy, nextID func() uint64) (*wsConn, error) {
+	w := &wsConn{
+		url:     url,
+		dialer:  websocket.DefaultDialer,
+		backoff: backoff,
+		nextID:  nextID,
+		done:    make(chan struct{}),
+		pending: make(map[uint64]wsCall),
+		subs:    make(map[string]*wsSubscription),
+		active:  make(map[*wsSubscription]struct{}),
+		orphans: make(map[string][]json.RawMessage),
+	}
+
+	conn, _, err := w.dialer.DialContext(ctx, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	w.conn = conn
+	go w.readLoop(conn)
+	return w, nil
+}
+
+// close shuts the connection down for good, active subscriptions are left without notifications.
+func (w *wsConn) close() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return
+	}
+	w.closed = true
+	close(w.done)
+	if w.conn != nil {
+		w.conn.Close()
+	}
+}
+
+// call sends a request on the current connection and decodes its result into result.
+func (w *wsConn) call(ctx context.Context, method string, params []interface{}, result interface{}) error {
+	w.mu.Lock()
+	conn := w.conn
+	if conn == nil {
+		w.mu.Unlock()
+		return errWSNotConnected
+	}
+	id := w.nextID()
+	w.lastID = max(w.lastID, id)
+	ch := make(chan wsResult, 1)
+	w.pending[id] = wsCall{method: method, ch: ch}
+	w.mu.Unlock()
+
+	req := newWireRequest(id, Request{Method: method, Params: params})
+	w.writeMu.Lock()
+	err := conn.WriteJSON(req)
+	w.writeMu.Unlock()
+	if err != nil {
+		w.forget(id)
+		return err
+	}
+
+	select {
+	case res := <-ch:
+		if res.err != nil {
+			return res.err
+		}
+		if res.msg.Error != nil {
+			return res.msg.Error
+		}
+		if result == nil {
+			return nil
+		}
+		return json.Unmarshal(res.msg.Result, result)
+	case <-ctx.Done():
+		w.forget(id)
+		return ctx.Err()
+	}
+}
+
+func (w *wsConn) forget(id uint64) {
+	w.mu.Lock()
+	delete(w.pending, id)
+	w.mu.Unlock()
+}
+
+// subscribe starts an eth_subscribe subscription, deliver decodes each notification and hands it to the consumer.
+func (w *wsConn) subscribe(ctx context.Context, params []interface{}, deliver func(raw json.RawMessage, quit <-chan struct{}) error) (*Subscription, error) {
+	sub := &wsSubscription{
+		params:  params,
+		deliver: deliver,
+		queue:   make(chan json.RawMessage, wsQueueSize),
+	}
+	sub.Subscription = newSubscription(func() { w.unsubscribe(sub) })
+
+	var id string
+	if err := w.call(ctx, "eth_subscribe", params, &id); err != nil {
+		return nil, err
+	}
+
+	w.mu.Lock()
+	w.active[sub] = struct{}{}
+	w.register(sub, id)
+	w.mu.Unlock()
+
+	go sub.forward()
+	return sub.Subscription, nil
+}
+
+// register maps the server side id to the subscription and replays the notifications that raced its registration.
+// It must be called with mu held.
+func (w *wsConn) register(sub *wsSubscription, id string) {
+	sub.id = id
+	w.subs[id] = sub
+	for _, raw := range w.orphans[id] {
+		w.enqueue(sub, raw)
+	}
+	delete(w.orphans, id)
+}
+
+func (w *wsConn) unsubscribe(sub *wsSubscription) {
+	w.mu.Lock()
+	delete(w.active, sub)
+	id := sub.id
+	registered := w.subs[id] == sub
+	if registered {
+		delete(w.subs, id)
+	}
+	w.mu.Unlock()
+
+	// Subscriptions of a previous connection died along with it
+	if !registered {
+		return
+	}
+
+	// Best effort, the server drops the subscription along with the connection anyway
+	ctx, cancel := context.WithTimeout(context.Background(), wsResubscribeTimeout)
+	defer cancel()
+	if err := w.call(ctx, "eth_unsubscribe", []interface{}{id}, nil); err != nil && !errors.Is(err, errWSNotConnected) {
+		slog.Warn("error unsubscribing", "subscription", id, "error", err)
+	}
+}
+
+// enqueue hands a notification to the subscription without blocking the read loop.
+// It must be called with mu held.
+func (w *wsConn) enqueue(sub *wsSubscription, raw json.RawMessage) {
+	select {
+	case sub.queue <- raw:
+	default:
+		// Failing unsubscribes, which needs mu
+		go sub.fail(ErrSubscriptionQueueOverflow)
+	}
+}
+
+func (w *wsConn) readLoop(conn *websocket.Conn) {
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			w.disconnected(conn, err)
+			return
+		}
+
+		var msg wsMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			slog.Warn("error unmarshalling WebSocket message", "endpoint", RedactURL(w.url), "error", err)
+			continue
+		}
+
+		switch {
+		case msg.Method == "eth_subscription":
+			w.dispatch(msg.Params)
+		case msg.Method == "":
+			w.reply(msg)
+		}
+	}
+}
+
+// reply hands a response to the call of its id.
+// A response no call can be matched with fails every pending call, as the one it answers cannot be told.
+func (w *wsConn) reply(msg wsMessage) {
+	w.mu.Lock()
+	id, err := strconv.ParseUint(string(msg.ID), 10, 64)
+	if err != nil || id > w.lastID {
+		pending := w.pending
+		w.pending = make(map[uint64]wsCall)
+		w.mu.Unlock()
+		slog.Warn("WebSocket response to no request", "endpoint", RedactURL(w.url), "id", string(msg.ID))
+		for _, call := range pending {
+			call.ch <- wsResult{err: &ProtocolError{Method: call.method, Reason: fmt.Sprintf("unexpected id %s", msg.ID)}}
+		}
+		return
+	}
+	call, ok := w.pending[id]
+	delete(w.pending, id)
+	w.mu.Unlock()
+
+	// The call may have given up waiting
+	if !ok {
+		slog.Warn("dropping WebSocket response with unknown id", "endpoint", RedactURL(w.url), "id", id)
+		return
+	}
+	if msg.Version != "2.0" {
+		call.ch <- wsResult{err: &ProtocolError{Method: call.method, Reason: fmt.Sprintf("unexpected jsonrpc version %q", msg.Version)}}
+		return
+	}
+	call.ch <- wsResult{msg: msg}
+}
+
+func (w *wsConn) dispatch(params json.RawMessage) {
+	var notification struct {
+		Subscription string          `json:"subscription"`
+		Result       json.RawMessage `json:"result"`
+	}
+	if err := json.Unmarshal(params, &notification); err != nil {
+		slog.Warn("error unmarshalling subscription notification", "endpoint", RedactURL(w.url), "error", err)
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	sub, ok := w.subs[notification.Subscription]
+	if !ok {
+		orphans, known := w.orphans[notification.Subscription]
+		if (known || len(w.orphans) < wsOrphanLimit) && len(orphans) < wsOrphanLimit {
+			w.orphans[notification.Subscription] = append(orphans, notification.Result)
+		}
+		return
+	}
+	w.enqueue(sub, notification.Result)
+}
+
+// disconnected fails the in-flight calls and, unless closed, reconnects in the background.
+func (w *wsConn) disconnected(conn *websocket.Conn, err error) {
+	w.mu.Lock()
+	if w.conn != conn {
+		w.mu.Unlock()
+		return
+	}
+	conn.Close()
+	w.conn = nil
+	pending := w.pending
+	w.pending = make(map[uint64]wsCall)
+	w.subs = make(map[string]*wsSubscription)
+	closed := w.closed
+	w.mu.Unlock()
+
+	for _, call := range pending {
+		call.ch <- wsResult{err: err}
+	}
+	if closed {
+		return
+	}
+	slog.Warn("WebSocket connection lost, reconnecting", "endpoint", RedactURL(w.url), "error", err)
+	go w.reconnect()
+}
+
+// reconnect dials until it succeeds or the connection is closed, then subscribes again.
+func (w *wsConn) reconnect() {
+	for attempt := 1; ; attempt++ {
+		timer := time.NewTimer(w.backoff.BaseDelay + w.backoff.Backoff(attempt))
+		select {
+		case <-w.done:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		conn, _, err := w.dialer.Dial(w.url, nil)
+		if err != nil {
+			slog.Warn("error reconnecting WebSocket", "endpoint", RedactURL(w.url), "attempt", attempt, "error", err)
+			continue
+		}
+
+		w.mu.Lock()
+		if w.closed {
+			w.mu.Unlock()
+			conn.Close()
+			return
+		}
+		w.conn = conn
+		subs := make([]*wsSubscription, 0, len(w.active))
+		for sub := range w.active {
+			subs = append(subs, sub)
+		}
+		w.mu.Unlock()
+
+		go w.readLoop(conn)
+		slog.Info("WebSocket reconnected, resubscribing", "endpoint", RedactURL(w.url), "subscriptions", len(subs))
+		for _, sub := range subs {
+			go w.resubscribe(conn, sub)
+		}
+		return
+	}
+}
+
+// resubscribe renews the subscription on the connection, retrying with backoff until it succeeds or the subscription ends.
+// It gives up when the connection drops, the next connection renews the subscription again.
+func (w *wsConn) resubscribe(conn *websocket.Conn, sub *wsSubscription) {
+	for attempt := 1; ; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), wsResubscribeTimeout)
+		var id string
+		err := w.call(ctx, "eth_subscribe", sub.params, &id)
+		cancel()
+
+		w.mu.Lock()
+		_, active := w.active[sub]
+		current := w.conn == conn
+		if err == nil && active && current {
+			w.register(sub, id)
+		}
+		w.mu.Unlock()
+		if err == nil || !active || !current {
+			return
+		}
+		// Rejected params are rejected again on every attempt
+		if isRequestError(err) {
+			sub.fail(err)
+			return
+		}
+		slog.Warn("error resubscribing, retrying", "endpoint", RedactURL(w.url), "subscription", sub.params[0], "attempt", attempt, "error", err)
+
+		timer := time.NewTimer(w.backoff.BaseDelay + w.backoff.Backoff(attempt))
+		select {
+		case <-w.done:
+			timer.Stop()
+			return
+		case <-sub.quit:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// forward hands the queued notifications to the consumer until the subscription ends.
+func (sub *wsSubscription) forward() {
+	for {
+		select {
+		case raw := <-sub.queue:
+			if err := sub.deliver(raw, sub.quit); err != nil {
+				sub.fail(err)
+				return
+			}
+		case <-sub.quit:
+			return
+		}
+	}
+}
@@ -67,7 +67,12 @@ resource "aws_ecs_task_definition" "polygon_client" {
     {
       "name": "polygon-client",
       "image": "${local.repository_url == "" ? aws_ecr_repository.polygon_client.repository_url : local.repository_url}:latest",
-      
+      "portMappings": [
+        {
+          "containerPort": 3000
+        }
+      ],
+
       "logConfiguration": {
         "logDriver": "awslogs",
         "options": {
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// serviceName is reported as the service.name resource attribute, unless overridden with OTEL_SERVICE_NAME.
+const serviceName = "polygon-client"
+
+// tracer creates the poll cycle spans, the RPC client creates their children through the same global provider.
+var tracer = otel.Tracer("github.com/rafaribe/polygon-client")
+
+// setupTracing installs a global tracer provider exporting spans over OTLP/HTTP to the collector at endpoint.
+// The returned function flushes the pending spans and must be called before exiting.
+func setupTracing(ctx context.Context, endpoint string) (shutdown func(context.Context) error, err error) {
+	exporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(endpoint))
+	if err != nil {
+		return nil, fmt.Errorf("error creating OTLP exporter: %v", err)
+	}
+	res, err := resource.New(ctx,
+		resource.WithAttributes(semconv.ServiceName(serviceName)),
+		resource.WithTelemetrySDK(),
+		resource.WithFromEnv(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error creating tracing resource: %v", err)
+	}
+
+	tp := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter), sdktrace.WithResource(res))
+	otel.SetTracerProvider(tp)
+	return tp.Shutdown, nil
+}
+
+// endSpan records err, if any, on the span and ends it.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// blockRangeAttributes describes the blocks processed by a cycle.
+func blockRangeAttributes(from, to uint64) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.Int64("polygon.block.from", int64(from)),
+		attribute.Int64("polygon.block.to", int64(to)),
+		attribute.Int64("polygon.block.count", int64(to-from+1)),
+	}
+}